@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffStrategy returns how long to wait before the given retry attempt, attempts start at 1
+// previous is the delay waited before the last retry (zero on the first one)
+type BackoffStrategy func(attempt int, previous time.Duration) time.Duration
+
+// ConstantBackoff waits the same delay between every attempt
+func ConstantBackoff(delay time.Duration) BackoffStrategy {
+	return func(int, time.Duration) time.Duration {
+		return delay
+	}
+}
+
+// LinearBackoff waits minDelay times the attempt number, capped at maxDelay
+func LinearBackoff(minDelay, maxDelay time.Duration) BackoffStrategy {
+	return func(attempt int, _ time.Duration) time.Duration {
+		return capDelay(minDelay*time.Duration(attempt), minDelay, maxDelay)
+	}
+}
+
+// ExponentialBackoff doubles the delay on every attempt starting at minDelay, capped at maxDelay
+func ExponentialBackoff(minDelay, maxDelay time.Duration) BackoffStrategy {
+	return func(attempt int, _ time.Duration) time.Duration {
+		delay := minDelay
+		for i := 1; i < attempt; i++ {
+			if maxDelay > 0 && delay >= maxDelay || delay > math.MaxInt64/2 {
+				break
+			}
+
+			delay *= 2
+		}
+
+		return capDelay(delay, minDelay, maxDelay)
+	}
+}
+
+// DecorrelatedJitterBackoff picks a random delay between minDelay and three times the previous delay, capped at maxDelay
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter
+func DecorrelatedJitterBackoff(minDelay, maxDelay time.Duration) BackoffStrategy {
+	return func(_ int, previous time.Duration) time.Duration {
+		if previous < minDelay {
+			previous = minDelay
+		}
+
+		upper := previous * 3
+		if upper <= minDelay {
+			return capDelay(minDelay, minDelay, maxDelay)
+		}
+
+		// #nosec G404 -- jitter does not need a cryptographically secure source
+		delay := minDelay + time.Duration(rand.Int63n(int64(upper-minDelay)))
+
+		return capDelay(delay, minDelay, maxDelay)
+	}
+}
+
+// capDelay keeps the delay between minDelay and maxDelay, a zero maxDelay means no upper cap
+func capDelay(delay, minDelay, maxDelay time.Duration) time.Duration {
+	if delay < minDelay {
+		delay = minDelay
+	}
+
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay
+}
+
+// sleepWithContext waits the given delay or until the context is done, whatever happens first
+func sleepWithContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffStrategies(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name     string
+		backoff  BackoffStrategy
+		attempt  int
+		expected time.Duration
+	}{
+		{name: "constant first attempt", backoff: ConstantBackoff(time.Second), attempt: 1, expected: time.Second},
+		{name: "constant later attempt", backoff: ConstantBackoff(time.Second), attempt: 5, expected: time.Second},
+		{name: "linear first attempt", backoff: LinearBackoff(time.Second, 3*time.Second), attempt: 1, expected: time.Second},
+		{name: "linear second attempt", backoff: LinearBackoff(time.Second, 3*time.Second), attempt: 2, expected: 2 * time.Second},
+		{name: "linear capped", backoff: LinearBackoff(time.Second, 3*time.Second), attempt: 10, expected: 3 * time.Second},
+		{name: "exponential first attempt", backoff: ExponentialBackoff(100*time.Millisecond, time.Second), attempt: 1, expected: 100 * time.Millisecond},
+		{name: "exponential third attempt", backoff: ExponentialBackoff(100*time.Millisecond, time.Second), attempt: 3, expected: 400 * time.Millisecond},
+		{name: "exponential capped", backoff: ExponentialBackoff(100*time.Millisecond, time.Second), attempt: 100, expected: time.Second},
+		{name: "exponential without cap", backoff: ExponentialBackoff(time.Second, 0), attempt: 100, expected: time.Second << 33},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c.Equal(test.expected, test.backoff(test.attempt, 0))
+		})
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	c := require.New(t)
+
+	minDelay, maxDelay := 100*time.Millisecond, 2*time.Second
+	backoff := DecorrelatedJitterBackoff(minDelay, maxDelay)
+
+	var delay time.Duration
+	for attempt := 1; attempt <= 50; attempt++ {
+		previous := delay
+		delay = backoff(attempt, previous)
+
+		c.GreaterOrEqual(delay, minDelay)
+		c.LessOrEqual(delay, maxDelay)
+		c.LessOrEqual(delay, 3*max(previous, minDelay))
+	}
+}
+
+func TestSleepWithContext(t *testing.T) {
+	c := require.New(t)
+
+	c.NoError(sleepWithContext(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	c.ErrorIs(sleepWithContext(ctx, time.Minute), context.DeadlineExceeded)
+	c.Less(time.Since(start), time.Second)
+}
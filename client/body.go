@@ -0,0 +1,77 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// maxDrainSize is the max amount of bytes read from a discarded response body so its connection can be reused
+const maxDrainSize = 4 << 10
+
+var (
+	// ErrBodyNotReplayable when a request body can't be sent again on a retry
+	ErrBodyNotReplayable = errors.New("request body can not be replayed")
+)
+
+// BufferRequestBody reads the request body into memory and sets its GetBody func
+// so the same payload can be sent on every attempt. It is a no-op for requests
+// without body or that already know how to rewind it, like the ones built with
+// http.NewRequest from a bytes or strings reader
+func BufferRequestBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if closeErr := req.Body.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.Body, _ = req.GetBody()
+
+	return nil
+}
+
+// requestForAttempt returns the request to send on the given attempt with the attempt number on its context
+// the first attempt uses the original request body and later ones a copy with a rewound body
+func requestForAttempt(req *http.Request, attempt int) (*http.Request, error) {
+	ctx := context.WithValue(req.Context(), attemptKey{}, attempt)
+	if attempt == 1 {
+		return req.WithContext(ctx), nil
+	}
+
+	attemptReq := req.Clone(ctx)
+	if req.Body == nil || req.Body == http.NoBody {
+		return attemptReq, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, ErrBodyNotReplayable
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	attemptReq.Body = body
+
+	return attemptReq, nil
+}
+
+// drainAndClose discards what is left of a bounded part of the body and closes it
+// so the underlying connection can be reused
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.CopyN(io.Discard, body, maxDrainSize)
+	_ = body.Close()
+}
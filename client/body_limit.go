@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// BodyTooLargeError is returned when reading a response body bigger than the max size allowed
+// it matches ErrBodyTooLarge with errors.Is
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+// Error returns the error message with the limit
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("%s: over %d bytes", ErrBodyTooLarge, e.Limit)
+}
+
+// Is reports if the target is ErrBodyTooLarge
+func (e *BodyTooLargeError) Is(target error) bool {
+	return target == ErrBodyTooLarge
+}
+
+// limitedBody fails with a *BodyTooLargeError once more than limit bytes are read from it
+type limitedBody struct {
+	io.ReadCloser
+	limit int64
+	read  int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.read > b.limit {
+		return 0, &BodyTooLargeError{Limit: b.limit}
+	}
+
+	// reading one byte over the limit tells a body of exactly the limit size from a bigger one
+	if rest := b.limit + 1 - b.read; int64(len(p)) > rest {
+		p = p[:rest]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+
+	if b.read > b.limit {
+		return n - int(b.read-b.limit), &BodyTooLargeError{Limit: b.limit}
+	}
+
+	return n, err
+}
+
+// limitBody makes reading the response body fail past the client max response size, if it's set
+func (c *Client) limitBody(resp *http.Response) *http.Response {
+	if resp == nil || c.maxResponseSize <= 0 {
+		return resp
+	}
+
+	resp.Body = &limitedBody{ReadCloser: resp.Body, limit: c.maxResponseSize}
+
+	return resp
+}
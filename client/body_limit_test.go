@@ -0,0 +1,67 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pokt-foundation/utils-go/mock-client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_MaxResponseSize(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodGet, "https://dummy.com/small", http.StatusOK, "ohana")
+	mock.AddMockedResponse(http.MethodGet, "https://dummy.com/big", http.StatusOK, strings.Repeat("a", 1<<10))
+
+	tests := []struct {
+		name            string
+		maxResponseSize int64
+		url             string
+		expectedBody    string
+		expectedErr     error
+	}{
+		{name: "under the limit", maxResponseSize: 10, url: "https://dummy.com/small", expectedBody: "ohana"},
+		{name: "exactly the limit", maxResponseSize: 5, url: "https://dummy.com/small", expectedBody: "ohana"},
+		{name: "over the limit", maxResponseSize: 100, url: "https://dummy.com/big", expectedBody: strings.Repeat("a", 100), expectedErr: ErrBodyTooLarge},
+		{name: "no limit", url: "https://dummy.com/big", expectedBody: strings.Repeat("a", 1<<10)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := NewCustomClientWithOptions(CustomClientOpts{
+				Timeout:         time.Second,
+				MaxResponseSize: test.maxResponseSize,
+			})
+
+			resp, err := client.GetWithURLAndParams(test.url, nil, nil)
+			c.NoError(err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			c.Equal(test.expectedBody, string(body))
+
+			if test.expectedErr == nil {
+				c.NoError(err)
+				return
+			}
+
+			c.ErrorIs(err, test.expectedErr)
+
+			var tooLargeErr *BodyTooLargeError
+			c.ErrorAs(err, &tooLargeErr)
+			c.Equal(test.maxResponseSize, tooLargeErr.Limit)
+
+			// Once over the limit every read fails
+			_, err = resp.Body.Read(make([]byte, 1))
+			c.ErrorIs(err, ErrBodyTooLarge)
+		})
+	}
+}
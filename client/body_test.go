@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+type trackedBody struct {
+	io.Reader
+	closed *atomic.Int32
+}
+
+func (b trackedBody) Close() error {
+	b.closed.Add(1)
+	return nil
+}
+
+func TestBufferRequestBody(t *testing.T) {
+	c := require.New(t)
+
+	req, err := http.NewRequest(http.MethodPost, "https://dummy.com", io.MultiReader(strings.NewReader(`{"ohana":`), strings.NewReader(`"family"}`)))
+	c.NoError(err)
+	c.Nil(req.GetBody)
+
+	c.NoError(BufferRequestBody(req))
+	c.NotNil(req.GetBody)
+	c.Equal(int64(18), req.ContentLength)
+
+	for i := 0; i < 2; i++ {
+		body, err := req.GetBody()
+		c.NoError(err)
+
+		data, err := io.ReadAll(body)
+		c.NoError(err)
+		c.Equal(`{"ohana":"family"}`, string(data))
+	}
+
+	req, err = http.NewRequest(http.MethodGet, "https://dummy.com", nil)
+	c.NoError(err)
+	c.NoError(BufferRequestBody(req))
+	c.Nil(req.GetBody)
+}
+
+func TestRequestForAttempt(t *testing.T) {
+	c := require.New(t)
+
+	req, err := http.NewRequest(http.MethodPost, "https://dummy.com", io.MultiReader(strings.NewReader("ohana")))
+	c.NoError(err)
+
+	attemptReq, err := requestForAttempt(req, 1)
+	c.NoError(err)
+	c.Equal(req.Body, attemptReq.Body)
+	c.Equal(1, AttemptFromContext(attemptReq.Context()))
+
+	_, err = requestForAttempt(req, 2)
+	c.ErrorIs(err, ErrBodyNotReplayable)
+
+	c.NoError(BufferRequestBody(req))
+
+	attemptReq, err = requestForAttempt(req, 2)
+	c.NoError(err)
+	c.NotSame(req, attemptReq)
+	c.Equal(2, AttemptFromContext(attemptReq.Context()))
+
+	data, err := io.ReadAll(attemptReq.Body)
+	c.NoError(err)
+	c.Equal("ohana", string(data))
+}
+
+func TestClient_DoRequestWithRetriesReplaysBody(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries: 2,
+		Timeout: time.Second,
+		Backoff: ConstantBackoff(time.Millisecond),
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var closed atomic.Int32
+	var bodies []string
+
+	httpmock.RegisterResponder(http.MethodPost, "https://dummy.com", func(req *http.Request) (*http.Response, error) {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		bodies = append(bodies, string(data))
+
+		resp := httpmock.NewStringResponse(http.StatusOK, `{"ok": 1}`)
+		if len(bodies) < 3 {
+			resp.StatusCode = http.StatusInternalServerError
+		}
+		resp.Body = trackedBody{Reader: bytes.NewBufferString(`{"ok": 1}`), closed: &closed}
+
+		return resp, nil
+	})
+
+	response, err := client.PostWithURLJSONParams("https://dummy.com", map[string]string{
+		"ohana": "family",
+	}, http.Header{})
+	c.NoError(err)
+	c.Equal(http.StatusOK, response.StatusCode)
+	c.Equal([]string{`{"ohana":"family"}`, `{"ohana":"family"}`, `{"ohana":"family"}`}, bodies)
+	c.Equal(int32(2), closed.Load())
+	c.NoError(response.Body.Close())
+
+	bodies = nil
+
+	// Caller built requests with a one-shot reader are replayed too
+	req, err := http.NewRequest(http.MethodPost, "https://dummy.com", io.MultiReader(strings.NewReader("ohana")))
+	c.NoError(err)
+
+	response, err = client.DoRequestWithRetries(req)
+	c.NoError(err)
+	c.Equal(http.StatusOK, response.StatusCode)
+	c.Equal([]string{"ohana", "ohana", "ohana"}, bodies)
+	c.NoError(response.Body.Close())
+}
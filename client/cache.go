@@ -0,0 +1,483 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// CacheStatusHeader is set on the responses that go through the cache
+	// with CacheHit, CacheRevalidated or CacheMiss
+	CacheStatusHeader = "X-Cache-Status"
+	// CacheHit is the cache status of responses served from the cache
+	CacheHit = "HIT"
+	// CacheRevalidated is the cache status of cached responses the server said are still valid
+	CacheRevalidated = "REVALIDATED"
+	// CacheMiss is the cache status of responses from the server
+	CacheMiss = "MISS"
+
+	// heuristicFreshnessRatio is the part of the time since Last-Modified a response without
+	// explicit expiration is considered fresh, as suggested on RFC 7234
+	heuristicFreshnessRatio = 0.1
+)
+
+// cacheableStatusCodes are the status codes cacheable by default on RFC 7231
+var cacheableStatusCodes = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// CacheStorage keeps the cached responses by key, implementations must be safe for concurrent use
+// and must not modify the responses they store
+type CacheStorage interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, response *CachedResponse)
+	Delete(key string)
+}
+
+// CachedResponse is a response kept on the cache
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// VaryHeaders are the values of the request headers listed on the response Vary header
+	VaryHeaders http.Header
+	// RequestTime is when the request was sent and ResponseTime when its response was received
+	RequestTime  time.Time
+	ResponseTime time.Time
+}
+
+// size returns an estimation of the memory used by the response
+func (r *CachedResponse) size() int64 {
+	size := int64(len(r.Body))
+
+	for _, header := range []http.Header{r.Header, r.VaryHeaders} {
+		for key, values := range header {
+			size += int64(len(key))
+			for _, value := range values {
+				size += int64(len(value))
+			}
+		}
+	}
+
+	return size
+}
+
+// CacheMiddleware caches the responses of GET requests on the storage following RFC 7234
+// Fresh responses are served from the cache and stale ones are revalidated with ETag
+// and Last-Modified when possible. The Cache-Control directives of both requests and responses
+// are honored and responses to other methods invalidate the cached ones for the same URL
+// Responses to requests with Authorization or Cookie headers are only cached when they are
+// public, s-maxage or must-revalidate, as the cache is shared by every caller of the client
+func CacheMiddleware(storage CacheStorage) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &cacheTransport{storage: storage, next: next, now: time.Now}
+	}
+}
+
+type cacheTransport struct {
+	storage CacheStorage
+	next    http.RoundTripper
+	now     func() time.Time
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+
+	if req.Method != http.MethodGet {
+		return t.invalidate(key, req)
+	}
+
+	reqCC := parseCacheControl(req.Header)
+	if !usesCache(req.Header, reqCC) {
+		return t.next.RoundTrip(req)
+	}
+
+	cached, ok := cachedFor(t.storage, req)
+	if ok && cached.canServe(reqCC, t.now()) {
+		return cached.response(req, t.now(), CacheHit), nil
+	}
+
+	sendReq := req
+	if ok {
+		sendReq = withValidators(req, cached.Header)
+	}
+
+	requestTime := t.now()
+
+	resp, err := t.next.RoundTrip(sendReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		drainAndClose(resp.Body)
+
+		revalidated := cached.revalidate(resp.Header, requestTime, t.now())
+		t.storage.Set(key, revalidated)
+
+		return revalidated.response(req, t.now(), CacheRevalidated), nil
+	}
+
+	resp.Header.Set(CacheStatusHeader, CacheMiss)
+
+	if storable(req.Header, reqCC, resp) {
+		t.store(key, req, resp, requestTime)
+	}
+
+	return resp, nil
+}
+
+// invalidate sends the request removing the cached response for its URL if it may have changed it
+func (t *cacheTransport) invalidate(key string, req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || req.Method == http.MethodHead || req.Method == http.MethodOptions {
+		return resp, err
+	}
+
+	if resp.StatusCode < http.StatusBadRequest {
+		t.storage.Delete(key)
+	}
+
+	return resp, nil
+}
+
+// lookupCache returns the cached response for the request when it is fresh enough to be served
+// without asking the server
+func lookupCache(storage CacheStorage, req *http.Request, now time.Time) (*http.Response, bool) {
+	reqCC := parseCacheControl(req.Header)
+	if req.Method != http.MethodGet || !usesCache(req.Header, reqCC) {
+		return nil, false
+	}
+
+	cached, ok := cachedFor(storage, req)
+	if !ok || !cached.canServe(reqCC, now) {
+		return nil, false
+	}
+
+	return cached.response(req, now, CacheHit), true
+}
+
+// usesCache checks if the GET request can use the cache, requests with no-store
+// or with their own conditional headers skip it
+func usesCache(header http.Header, reqCC cacheControl) bool {
+	return !reqCC.has("no-store") && !hasConditionals(header)
+}
+
+// cachedFor returns the cached response for the request URL if it varies on the same header values
+func cachedFor(storage CacheStorage, req *http.Request) (*CachedResponse, bool) {
+	cached, ok := storage.Get(req.URL.String())
+	if !ok || !cached.varyMatches(req.Header) {
+		return nil, false
+	}
+
+	return cached, true
+}
+
+// canServe checks if the cached response is fresh enough for the request
+func (r *CachedResponse) canServe(reqCC cacheControl, now time.Time) bool {
+	respCC := parseCacheControl(r.Header)
+	if reqCC.has("no-cache") || respCC.has("no-cache") || r.Header.Get("Pragma") == "no-cache" {
+		return false
+	}
+
+	age := r.age(now)
+	lifetime := r.freshnessLifetime(respCC)
+
+	if maxAge, ok := reqCC.seconds("max-age"); ok && age > maxAge {
+		return false
+	}
+
+	if minFresh, ok := reqCC.seconds("min-fresh"); ok && lifetime-age < minFresh {
+		return false
+	}
+
+	if age < lifetime {
+		return true
+	}
+
+	if respCC.has("must-revalidate") || !reqCC.has("max-stale") {
+		return false
+	}
+
+	// max-stale without a value accepts stale responses of any age
+	maxStale, ok := reqCC.seconds("max-stale")
+
+	return !ok || age-lifetime <= maxStale
+}
+
+// store saves the response on the cache once its body is read to the end
+func (t *cacheTransport) store(key string, req *http.Request, resp *http.Response, requestTime time.Time) {
+	cached := &CachedResponse{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		VaryHeaders:  varyHeaders(resp.Header, req.Header),
+		RequestTime:  requestTime,
+		ResponseTime: t.now(),
+	}
+	cached.Header.Del(CacheStatusHeader)
+
+	resp.Body = &cachingBody{
+		ReadCloser: resp.Body,
+		limit:      defaultMaxResponseSize,
+		onEOF: func(body []byte) {
+			cached.Body = body
+			t.storage.Set(key, cached)
+		},
+	}
+}
+
+// response builds a new response for the request with the cached values
+func (r *CachedResponse) response(req *http.Request, now time.Time, status string) *http.Response {
+	header := r.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(r.age(now)/time.Second), 10))
+	header.Set(CacheStatusHeader, status)
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// revalidate returns a copy of the cached response updated with the headers of a 304 response
+func (r *CachedResponse) revalidate(header http.Header, requestTime, responseTime time.Time) *CachedResponse {
+	revalidated := *r
+	revalidated.Header = r.Header.Clone()
+	revalidated.RequestTime = requestTime
+	revalidated.ResponseTime = responseTime
+
+	for key, values := range header {
+		switch key {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding", CacheStatusHeader:
+			continue
+		}
+
+		revalidated.Header[key] = values
+	}
+
+	return &revalidated
+}
+
+// age returns the current age of the response as defined on RFC 7234 section 4.2.3
+func (r *CachedResponse) age(now time.Time) time.Duration {
+	var apparentAge time.Duration
+	if date, err := http.ParseTime(r.Header.Get("Date")); err == nil {
+		apparentAge = max(0, r.ResponseTime.Sub(date))
+	}
+
+	ageValue, _ := strconv.ParseInt(r.Header.Get("Age"), 10, 64)
+	correctedAgeValue := time.Duration(ageValue)*time.Second + r.ResponseTime.Sub(r.RequestTime)
+
+	return max(apparentAge, correctedAgeValue) + now.Sub(r.ResponseTime)
+}
+
+// freshnessLifetime returns how long the response is fresh as defined on RFC 7234 section 4.2.1
+// for shared caches, where s-maxage overrides max-age
+func (r *CachedResponse) freshnessLifetime(respCC cacheControl) time.Duration {
+	if sMaxAge, ok := respCC.seconds("s-maxage"); ok {
+		return sMaxAge
+	}
+
+	if maxAge, ok := respCC.seconds("max-age"); ok {
+		return maxAge
+	}
+
+	date, err := http.ParseTime(r.Header.Get("Date"))
+	if err != nil {
+		date = r.ResponseTime
+	}
+
+	if expiresHeader := r.Header.Get("Expires"); expiresHeader != "" {
+		// Invalid dates like 0 mean already expired
+		expires, err := http.ParseTime(expiresHeader)
+		if err != nil {
+			return 0
+		}
+
+		return expires.Sub(date)
+	}
+
+	lastModified, err := http.ParseTime(r.Header.Get("Last-Modified"))
+	if err != nil || lastModified.After(date) {
+		return 0
+	}
+
+	return time.Duration(float64(date.Sub(lastModified)) * heuristicFreshnessRatio)
+}
+
+// varyMatches checks if the request has the same values for the headers the response varies on
+func (r *CachedResponse) varyMatches(header http.Header) bool {
+	for key, values := range r.VaryHeaders {
+		if strings.Join(values, ",") != strings.Join(header.Values(key), ",") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// storable checks if the response can be kept on the cache
+func storable(reqHeader http.Header, reqCC cacheControl, resp *http.Response) bool {
+	if !cacheableStatusCodes[resp.StatusCode] || resp.Header.Get("Vary") == "*" {
+		return false
+	}
+
+	respCC := parseCacheControl(resp.Header)
+	if reqCC.has("no-store") || respCC.has("no-store") || !sharable(reqHeader, respCC) {
+		return false
+	}
+
+	// Responses without expiration or validators can't be used later
+	return hasExpiration(resp.Header, respCC) || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+func hasExpiration(header http.Header, respCC cacheControl) bool {
+	return respCC.has("max-age") || respCC.has("s-maxage") || header.Get("Expires") != ""
+}
+
+// sharable checks if the response can be served to other callers, as the cache is shared by all
+// the requests of the client, responses to requests with credentials are only kept when the server
+// allows it explicitly as defined on RFC 7234 section 3.2
+func sharable(reqHeader http.Header, respCC cacheControl) bool {
+	if reqHeader.Get("Authorization") == "" && reqHeader.Get("Cookie") == "" {
+		return true
+	}
+
+	return respCC.has("public") || respCC.has("s-maxage") || respCC.has("must-revalidate")
+}
+
+// varyHeaders returns the values of the request headers listed on the Vary header of the response
+func varyHeaders(respHeader, reqHeader http.Header) http.Header {
+	vary := http.Header{}
+
+	for _, value := range respHeader.Values("Vary") {
+		for _, key := range strings.Split(value, ",") {
+			key = http.CanonicalHeaderKey(strings.TrimSpace(key))
+			if key != "" {
+				vary[key] = reqHeader.Values(key)
+			}
+		}
+	}
+
+	return vary
+}
+
+// withValidators returns a copy of the request asking the server to answer 304 if the cached response is still valid
+func withValidators(req *http.Request, cachedHeader http.Header) *http.Request {
+	etag, lastModified := cachedHeader.Get("ETag"), cachedHeader.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	return req
+}
+
+// hasConditionals checks if the caller set its own conditional headers, those requests skip the cache
+func hasConditionals(header http.Header) bool {
+	return header.Get("If-None-Match") != "" || header.Get("If-Modified-Since") != "" ||
+		header.Get("If-Match") != "" || header.Get("If-Unmodified-Since") != "" || header.Get("If-Range") != ""
+}
+
+// cacheControl are the Cache-Control directives with their values
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := cacheControl{}
+
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				cc[strings.ToLower(name)] = strings.Trim(arg, `"`)
+			}
+		}
+	}
+
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// seconds returns the directive value as a duration
+func (cc cacheControl) seconds(directive string) (time.Duration, bool) {
+	seconds, err := strconv.ParseInt(cc[directive], 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+// cachingBody keeps what is read from the body and calls onEOF with it once it's read to the end
+// bodies bigger than the limit are not kept, the rest of the body is read on Close so the responses
+// closed before their EOF was read, like the ones decoded with json.Decoder, are kept too
+type cachingBody struct {
+	io.ReadCloser
+	buf   bytes.Buffer
+	limit int64
+	onEOF func(body []byte)
+	done  bool
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	if !b.done {
+		b.buf.Write(p[:n])
+
+		switch {
+		case int64(b.buf.Len()) > b.limit:
+			b.done = true
+			b.buf = bytes.Buffer{}
+		case err == io.EOF:
+			b.done = true
+			b.onEOF(b.buf.Bytes())
+		}
+	}
+
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	buf := make([]byte, 32*1024)
+	for !b.done {
+		if _, err := b.Read(buf); err != nil {
+			break
+		}
+	}
+
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"container/list"
+	"sync"
+)
+
+// LRUCache is an in memory CacheStorage that evicts the least recently used responses
+// once it goes over its max amount of entries or bytes
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	entries    *list.List
+	items      map[string]*list.Element
+}
+
+type lruEntry struct {
+	key      string
+	response *CachedResponse
+	size     int64
+}
+
+// NewLRUCache returns an LRU cache with the given limits, a zero limit means no limit
+func NewLRUCache(maxEntries int, maxBytes int64) *LRUCache {
+	return &LRUCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get returns the response stored with the key
+func (c *LRUCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.entries.MoveToFront(element)
+
+	return element.Value.(*lruEntry).response, true
+}
+
+// Set stores the response with the key, responses bigger than the max bytes are not stored
+func (c *LRUCache) Set(key string, response *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(key)
+
+	size := response.size()
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+
+	c.items[key] = c.entries.PushFront(&lruEntry{key: key, response: response, size: size})
+	c.bytes += size
+
+	for c.maxEntries > 0 && c.entries.Len() > c.maxEntries || c.maxBytes > 0 && c.bytes > c.maxBytes {
+		c.remove(c.entries.Back().Value.(*lruEntry).key)
+	}
+}
+
+// Delete removes the response stored with the key
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(key)
+}
+
+// Len returns the amount of responses stored
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries.Len()
+}
+
+// Bytes returns the size of the responses stored
+func (c *LRUCache) Bytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.bytes
+}
+
+// remove deletes the entry, must be called with the lock held
+func (c *LRUCache) remove(key string) {
+	element, ok := c.items[key]
+	if !ok {
+		return
+	}
+
+	c.entries.Remove(element)
+	delete(c.items, key)
+	c.bytes -= element.Value.(*lruEntry).size
+}
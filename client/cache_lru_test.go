@@ -0,0 +1,55 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := require.New(t)
+
+	cache := NewLRUCache(2, 10)
+
+	cache.Set("a", &CachedResponse{Body: []byte("aaa")})
+	cache.Set("b", &CachedResponse{Body: []byte("bbb")})
+
+	response, ok := cache.Get("a")
+	c.True(ok)
+	c.Equal("aaa", string(response.Body))
+
+	// b is the least recently used one
+	cache.Set("c", &CachedResponse{Body: []byte("ccc")})
+	c.Equal(2, cache.Len())
+	c.Equal(int64(6), cache.Bytes())
+
+	_, ok = cache.Get("b")
+	c.False(ok)
+
+	// Going over the max bytes evicts entries too
+	cache.Set("d", &CachedResponse{Body: []byte("dddddd")})
+	c.Equal(2, cache.Len())
+	c.Equal(int64(9), cache.Bytes())
+
+	_, ok = cache.Get("a")
+	c.False(ok)
+
+	// Responses bigger than the max bytes are not stored
+	cache.Set("e", &CachedResponse{Body: []byte("eeeeeeeeeee")})
+	_, ok = cache.Get("e")
+	c.False(ok)
+
+	// Replacing an entry updates its size
+	cache.Set("d", &CachedResponse{Body: []byte("d")})
+	c.Equal(int64(4), cache.Bytes())
+
+	cache.Delete("d")
+	c.Equal(1, cache.Len())
+	c.Equal(int64(3), cache.Bytes())
+
+	unlimited := NewLRUCache(0, 0)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		unlimited.Set(key, &CachedResponse{Body: []byte(key)})
+	}
+	c.Equal(4, unlimited.Len())
+}
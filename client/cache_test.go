@@ -0,0 +1,400 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheTest struct {
+	c         *require.Assertions
+	now       time.Time
+	calls     int
+	requests  []*http.Request
+	respond   func(req *http.Request) *http.Response
+	transport *cacheTransport
+}
+
+func newCacheTest(c *require.Assertions) *cacheTest {
+	test := &cacheTest{c: c, now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	test.transport = &cacheTransport{
+		storage: NewLRUCache(0, 0),
+		now:     func() time.Time { return test.now },
+		next: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			test.calls++
+			test.requests = append(test.requests, req)
+			return test.respond(req), nil
+		}),
+	}
+
+	return test
+}
+
+// get does a GET request returning the cache status and the body read to the end
+func (test *cacheTest) get(header http.Header) (string, string) {
+	req, err := http.NewRequest(http.MethodGet, "https://dummy.com/metadata", nil)
+	test.c.NoError(err)
+
+	if header != nil {
+		req.Header = header
+	}
+
+	resp, err := test.transport.RoundTrip(req)
+	test.c.NoError(err)
+
+	body, err := io.ReadAll(resp.Body)
+	test.c.NoError(err)
+	test.c.NoError(resp.Body.Close())
+
+	return resp.Header.Get(CacheStatusHeader), string(body)
+}
+
+func cachedResponse(statusCode int, body string, header http.Header) *http.Response {
+	resp := httpmock.NewStringResponse(statusCode, body)
+	for key, values := range header {
+		resp.Header[key] = values
+	}
+
+	return resp
+}
+
+func TestCacheMiddleware_MaxAge(t *testing.T) {
+	c := require.New(t)
+
+	test := newCacheTest(c)
+	test.respond = func(req *http.Request) *http.Response {
+		return cachedResponse(http.StatusOK, "v"+strconv.Itoa(test.calls), http.Header{
+			"Cache-Control": []string{"public, max-age=60"},
+			"Date":          []string{test.now.Format(http.TimeFormat)},
+		})
+	}
+
+	status, body := test.get(nil)
+	c.Equal(CacheMiss, status)
+	c.Equal("v1", body)
+
+	test.now = test.now.Add(30 * time.Second)
+
+	status, body = test.get(nil)
+	c.Equal(CacheHit, status)
+	c.Equal("v1", body)
+	c.Equal(1, test.calls)
+
+	// Request directives can ask for fresher responses
+	status, _ = test.get(http.Header{"Cache-Control": []string{"max-age=10"}})
+	c.Equal(CacheMiss, status)
+	c.Equal(2, test.calls)
+
+	status, _ = test.get(http.Header{"Cache-Control": []string{"min-fresh=50"}})
+	c.Equal(CacheHit, status)
+
+	test.now = test.now.Add(30 * time.Second)
+
+	status, _ = test.get(http.Header{"Cache-Control": []string{"min-fresh=50"}})
+	c.Equal(CacheMiss, status)
+	c.Equal(3, test.calls)
+
+	// Stale responses are served just if the request accepts them
+	test.now = test.now.Add(70 * time.Second)
+
+	status, body = test.get(http.Header{"Cache-Control": []string{"max-stale=20"}})
+	c.Equal(CacheHit, status)
+	c.Equal("v3", body)
+
+	status, body = test.get(nil)
+	c.Equal(CacheMiss, status)
+	c.Equal("v4", body)
+}
+
+func TestCacheMiddleware_Revalidation(t *testing.T) {
+	c := require.New(t)
+
+	test := newCacheTest(c)
+	test.respond = func(req *http.Request) *http.Response {
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			return cachedResponse(http.StatusNotModified, "", http.Header{"X-Revalidated": []string{"true"}})
+		}
+
+		return cachedResponse(http.StatusOK, "ohana", http.Header{
+			"Cache-Control": []string{"no-cache"},
+			"Etag":          []string{`"v1"`},
+			"Last-Modified": []string{test.now.Add(-time.Hour).Format(http.TimeFormat)},
+		})
+	}
+
+	status, body := test.get(nil)
+	c.Equal(CacheMiss, status)
+	c.Equal("ohana", body)
+
+	status, body = test.get(nil)
+	c.Equal(CacheRevalidated, status)
+	c.Equal("ohana", body)
+	c.Equal(2, test.calls)
+	c.Equal(`"v1"`, test.requests[1].Header.Get("If-None-Match"))
+	c.NotEmpty(test.requests[1].Header.Get("If-Modified-Since"))
+
+	cached, ok := test.transport.storage.Get("https://dummy.com/metadata")
+	c.True(ok)
+	c.Equal("true", cached.Header.Get("X-Revalidated"))
+
+	// Requests with their own conditionals skip the cache
+	status, body = test.get(http.Header{"If-None-Match": []string{`"v1"`}})
+	c.Empty(status)
+	c.Empty(body)
+}
+
+func TestCacheMiddleware_HeuristicFreshness(t *testing.T) {
+	c := require.New(t)
+
+	test := newCacheTest(c)
+	test.respond = func(req *http.Request) *http.Response {
+		return cachedResponse(http.StatusOK, "ohana", http.Header{
+			"Date":          []string{test.now.Format(http.TimeFormat)},
+			"Last-Modified": []string{test.now.Add(-100 * time.Second).Format(http.TimeFormat)},
+		})
+	}
+
+	test.get(nil)
+
+	test.now = test.now.Add(9 * time.Second)
+	status, _ := test.get(nil)
+	c.Equal(CacheHit, status)
+
+	test.now = test.now.Add(time.Second)
+	status, _ = test.get(nil)
+	c.Equal(CacheMiss, status)
+}
+
+func TestCacheMiddleware_NotStored(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		header     http.Header
+		reqHeader  http.Header
+	}{
+		{name: "no store response", statusCode: http.StatusOK, header: http.Header{"Cache-Control": []string{"no-store, max-age=60"}}},
+		{name: "no store request", statusCode: http.StatusOK, header: http.Header{"Cache-Control": []string{"max-age=60"}}, reqHeader: http.Header{"Cache-Control": []string{"no-store"}}},
+		{name: "no expiration nor validators", statusCode: http.StatusOK, header: http.Header{}},
+		{name: "not cacheable status", statusCode: http.StatusInternalServerError, header: http.Header{"Cache-Control": []string{"max-age=60"}}},
+		{name: "vary on everything", statusCode: http.StatusOK, header: http.Header{"Cache-Control": []string{"max-age=60"}, "Vary": []string{"*"}}},
+		{name: "expired", statusCode: http.StatusOK, header: http.Header{"Expires": []string{"0"}}},
+		{name: "authorization", statusCode: http.StatusOK, header: http.Header{"Cache-Control": []string{"max-age=60"}}, reqHeader: http.Header{"Authorization": []string{"Bearer alice"}}},
+		{name: "cookie", statusCode: http.StatusOK, header: http.Header{"Cache-Control": []string{"max-age=60"}}, reqHeader: http.Header{"Cookie": []string{"session=alice"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := newCacheTest(c)
+			test.respond = func(req *http.Request) *http.Response {
+				return cachedResponse(tt.statusCode, "ohana", tt.header)
+			}
+
+			test.get(tt.reqHeader)
+			test.get(tt.reqHeader)
+			c.Equal(2, test.calls)
+		})
+	}
+}
+
+func TestCacheMiddleware_Authorization(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name           string
+		cacheControl   string
+		expectedStatus string
+		expectedBody   string
+	}{
+		{name: "private", cacheControl: "max-age=60", expectedStatus: CacheMiss, expectedBody: "Bearer bob"},
+		{name: "public", cacheControl: "public, max-age=60", expectedStatus: CacheHit, expectedBody: "Bearer alice"},
+		{name: "s-maxage", cacheControl: "s-maxage=60", expectedStatus: CacheHit, expectedBody: "Bearer alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := newCacheTest(c)
+			test.respond = func(req *http.Request) *http.Response {
+				return cachedResponse(http.StatusOK, req.Header.Get("Authorization"), http.Header{
+					"Cache-Control": []string{tt.cacheControl},
+				})
+			}
+
+			_, body := test.get(http.Header{"Authorization": []string{"Bearer alice"}})
+			c.Equal("Bearer alice", body)
+
+			status, body := test.get(http.Header{"Authorization": []string{"Bearer bob"}})
+			c.Equal(tt.expectedStatus, status)
+			c.Equal(tt.expectedBody, body)
+		})
+	}
+}
+
+func TestCacheMiddleware_Vary(t *testing.T) {
+	c := require.New(t)
+
+	test := newCacheTest(c)
+	test.respond = func(req *http.Request) *http.Response {
+		return cachedResponse(http.StatusOK, req.Header.Get("Accept"), http.Header{
+			"Cache-Control": []string{"max-age=60"},
+			"Vary":          []string{"Accept"},
+		})
+	}
+
+	_, body := test.get(http.Header{"Accept": []string{"application/json"}})
+	c.Equal("application/json", body)
+
+	status, body := test.get(http.Header{"Accept": []string{"application/json"}})
+	c.Equal(CacheHit, status)
+	c.Equal("application/json", body)
+
+	status, body = test.get(http.Header{"Accept": []string{"text/plain"}})
+	c.Equal(CacheMiss, status)
+	c.Equal("text/plain", body)
+}
+
+func TestClient_Cache(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Cache: NewLRUCache(100, 1<<20),
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/metadata", func(req *http.Request) (*http.Response, error) {
+		return cachedResponse(http.StatusOK, `{"ohana": "family"}`, http.Header{"Cache-Control": []string{"max-age=60"}}), nil
+	})
+	httpmock.RegisterResponder(http.MethodPut, "https://dummy.com/metadata", httpmock.NewStringResponder(http.StatusOK, ""))
+
+	get := func() string {
+		response, err := client.GetWithURLAndParams("https://dummy.com/metadata", url.Values{}, nil)
+		c.NoError(err)
+
+		_, err = io.ReadAll(response.Body)
+		c.NoError(err)
+		c.NoError(response.Body.Close())
+
+		return response.Header.Get(CacheStatusHeader)
+	}
+
+	c.Equal(CacheMiss, get())
+	c.Equal(CacheHit, get())
+	c.Equal(1, httpmock.GetCallCountInfo()["GET https://dummy.com/metadata"])
+
+	// Unsafe methods invalidate the cached response
+	response, err := client.PutWithURLJSONParams("https://dummy.com/metadata", map[string]string{"ohana": "family"}, nil)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	c.Equal(CacheMiss, get())
+	c.Equal(2, httpmock.GetCallCountInfo()["GET https://dummy.com/metadata"])
+}
+
+func TestClient_CacheBeforeLimits(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/metadata", func(req *http.Request) (*http.Response, error) {
+		return cachedResponse(http.StatusOK, `{"ohana": "family"}`, http.Header{"Cache-Control": []string{"max-age=60"}}), nil
+	})
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/fail", httpmock.NewStringResponder(http.StatusInternalServerError, ""))
+
+	get := func(client *Client, rawURL string) (string, error) {
+		response, err := client.GetWithURLAndParams(rawURL, url.Values{}, nil)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = io.ReadAll(response.Body)
+		c.NoError(err)
+		c.NoError(response.Body.Close())
+
+		return response.Header.Get(CacheStatusHeader), nil
+	}
+
+	// Cached responses don't wait for the rate limit
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Cache:     NewLRUCache(100, 1<<20),
+		RateLimit: &RateLimit{RequestsPerSecond: 1},
+	})
+
+	status, err := get(client, "https://dummy.com/metadata")
+	c.NoError(err)
+	c.Equal(CacheMiss, status)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		status, err = get(client, "https://dummy.com/metadata")
+		c.NoError(err)
+		c.Equal(CacheHit, status)
+	}
+	c.Less(time.Since(start), 500*time.Millisecond)
+
+	// Cached responses are served while the circuit of their host is open
+	client = NewCustomClientWithOptions(CustomClientOpts{
+		Cache:          NewLRUCache(100, 1<<20),
+		CircuitBreaker: &CircuitBreakerOpts{MinRequests: 1, Cooldown: time.Minute},
+	})
+
+	status, err = get(client, "https://dummy.com/metadata")
+	c.NoError(err)
+	c.Equal(CacheMiss, status)
+
+	_, err = get(client, "https://dummy.com/fail")
+	c.NoError(err)
+	c.Equal(CircuitOpen, client.CircuitState("dummy.com"))
+
+	status, err = get(client, "https://dummy.com/metadata")
+	c.NoError(err)
+	c.Equal(CacheHit, status)
+
+	_, err = get(client, "https://dummy.com/fail")
+	c.ErrorIs(err, ErrCircuitOpen)
+
+	c.Equal(2, httpmock.GetCallCountInfo()["GET https://dummy.com/metadata"])
+}
+
+func TestClient_CacheDecodedBody(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Cache: NewLRUCache(100, 1<<20),
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/metadata", func(req *http.Request) (*http.Response, error) {
+		return cachedResponse(http.StatusOK, `{"ohana": "family"}`+"\n", http.Header{"Cache-Control": []string{"max-age=60"}}), nil
+	})
+
+	// The body is closed once decoded, without reading its EOF
+	get := func() string {
+		response, err := client.GetWithURLAndParams("https://dummy.com/metadata", url.Values{}, nil)
+		c.NoError(err)
+
+		var value map[string]string
+		c.NoError(json.NewDecoder(response.Body).Decode(&value))
+		c.NoError(response.Body.Close())
+		c.Equal("family", value["ohana"])
+
+		return response.Header.Get(CacheStatusHeader)
+	}
+
+	c.Equal(CacheMiss, get())
+	c.Equal(CacheHit, get())
+	c.Equal(1, httpmock.GetCallCountInfo()["GET https://dummy.com/metadata"])
+}
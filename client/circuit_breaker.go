@@ -0,0 +1,253 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCircuitFailureRatio     = 0.5
+	defaultCircuitMinRequests      = 10
+	defaultCircuitWindow           = time.Minute
+	defaultCircuitCooldown         = 30 * time.Second
+	defaultCircuitHalfOpenRequests = 1
+)
+
+var (
+	// ErrCircuitOpen when a request is not sent because the circuit breaker of its host is open
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+// CircuitState is the state of the circuit breaker of a host
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all requests until the cooldown is over
+	CircuitOpen
+	// CircuitHalfOpen lets a limited amount of trial requests through
+	CircuitHalfOpen
+)
+
+// String returns the name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerOpts are the options of the per host circuit breaker
+type CircuitBreakerOpts struct {
+	// FailureRatio of failed requests in a window that opens the circuit, defaults to 0.5
+	FailureRatio float64
+	// MinRequests in a window before the failure ratio is checked, defaults to 10
+	MinRequests int
+	// Window is how often the request counts of a closed circuit are reset, defaults to 1 minute
+	Window time.Duration
+	// Cooldown is how long the circuit stays open before letting trial requests through, defaults to 30 seconds
+	Cooldown time.Duration
+	// HalfOpenRequests is the amount of trial requests that must succeed to close the circuit again, defaults to 1
+	HalfOpenRequests int
+	// OnStateChange is called every time the circuit of a host changes its state
+	OnStateChange func(host string, from, to CircuitState)
+}
+
+type circuitResult int
+
+const (
+	circuitSuccess circuitResult = iota
+	circuitFailure
+	// circuitIgnored is for attempts cancelled by the caller, they don't say anything about the host
+	circuitIgnored
+)
+
+type hostCircuit struct {
+	state       CircuitState
+	successes   int
+	failures    int
+	windowStart time.Time
+	openedAt    time.Time
+	// generation changes with every state change, the requests allowed on a previous one don't count
+	generation uint64
+	// inFlight are the requests allowed on the current generation that are not done yet
+	inFlight int
+}
+
+type circuitBreaker struct {
+	opts  CircuitBreakerOpts
+	mu    sync.Mutex
+	hosts map[string]*hostCircuit
+	now   func() time.Time
+}
+
+func newCircuitBreaker(opts CircuitBreakerOpts) *circuitBreaker {
+	if opts.FailureRatio <= 0 {
+		opts.FailureRatio = defaultCircuitFailureRatio
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = defaultCircuitMinRequests
+	}
+	if opts.Window <= 0 {
+		opts.Window = defaultCircuitWindow
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = defaultCircuitCooldown
+	}
+	if opts.HalfOpenRequests <= 0 {
+		opts.HalfOpenRequests = defaultCircuitHalfOpenRequests
+	}
+
+	return &circuitBreaker{
+		opts:  opts,
+		hosts: make(map[string]*hostCircuit),
+		now:   time.Now,
+	}
+}
+
+// circuitResultFor classifies an attempt, transport errors and 5xx responses are failures
+func circuitResultFor(resp *http.Response, err error) circuitResult {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return circuitIgnored
+	case err != nil, resp.StatusCode >= http.StatusInternalServerError:
+		return circuitFailure
+	default:
+		return circuitSuccess
+	}
+}
+
+// state returns the current state of the host circuit
+func (cb *circuitBreaker) state(host string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	circuit, ok := cb.hosts[host]
+	if !ok {
+		return CircuitClosed
+	}
+
+	return circuit.state
+}
+
+// allow returns ErrCircuitOpen if a request to the host can't be sent right now, or the generation of the circuit
+// the request was allowed on, every allowed request must be followed by a call to done with it
+func (cb *circuitBreaker) allow(host string) (uint64, error) {
+	cb.mu.Lock()
+
+	circuit := cb.circuit(host)
+	from := circuit.state
+
+	if circuit.state == CircuitOpen && cb.now().Sub(circuit.openedAt) >= cb.opts.Cooldown {
+		cb.setState(circuit, CircuitHalfOpen)
+	}
+
+	allowed := circuit.state == CircuitClosed ||
+		circuit.state == CircuitHalfOpen && circuit.inFlight < cb.opts.HalfOpenRequests
+	if allowed {
+		circuit.inFlight++
+	}
+
+	to, generation := circuit.state, circuit.generation
+	cb.mu.Unlock()
+
+	cb.notify(host, from, to)
+
+	if !allowed {
+		return 0, ErrCircuitOpen
+	}
+
+	return generation, nil
+}
+
+// done records the result of a request allowed on the generation, results from previous generations are ignored
+// so requests sent before the circuit opened neither take the half open slots nor close it
+func (cb *circuitBreaker) done(host string, generation uint64, result circuitResult) {
+	cb.mu.Lock()
+
+	circuit := cb.circuit(host)
+	if circuit.generation != generation {
+		cb.mu.Unlock()
+		return
+	}
+
+	from := circuit.state
+	circuit.inFlight--
+
+	switch result {
+	case circuitSuccess:
+		circuit.successes++
+	case circuitFailure:
+		circuit.failures++
+	}
+
+	switch {
+	case circuit.state == CircuitHalfOpen && result == circuitFailure:
+		cb.setState(circuit, CircuitOpen)
+	case circuit.state == CircuitHalfOpen && circuit.successes >= cb.opts.HalfOpenRequests:
+		cb.setState(circuit, CircuitClosed)
+	case circuit.state == CircuitClosed && cb.tripped(circuit):
+		cb.setState(circuit, CircuitOpen)
+	}
+
+	to := circuit.state
+	cb.mu.Unlock()
+
+	cb.notify(host, from, to)
+}
+
+// circuit returns the host circuit, resetting the counts of closed circuits once their window is over
+// must be called with the lock held
+func (cb *circuitBreaker) circuit(host string) *hostCircuit {
+	now := cb.now()
+
+	circuit, ok := cb.hosts[host]
+	if !ok {
+		circuit = &hostCircuit{windowStart: now}
+		cb.hosts[host] = circuit
+	}
+
+	if circuit.state == CircuitClosed && now.Sub(circuit.windowStart) >= cb.opts.Window {
+		circuit.successes, circuit.failures = 0, 0
+		circuit.windowStart = now
+	}
+
+	return circuit
+}
+
+func (cb *circuitBreaker) tripped(circuit *hostCircuit) bool {
+	total := circuit.successes + circuit.failures
+	if total < cb.opts.MinRequests {
+		return false
+	}
+
+	return float64(circuit.failures)/float64(total) >= cb.opts.FailureRatio
+}
+
+// setState moves the circuit to the given state with fresh counts, must be called with the lock held
+func (cb *circuitBreaker) setState(circuit *hostCircuit, state CircuitState) {
+	circuit.state = state
+	circuit.generation++
+	circuit.successes, circuit.failures, circuit.inFlight = 0, 0, 0
+	circuit.windowStart = cb.now()
+
+	if state == CircuitOpen {
+		circuit.openedAt = circuit.windowStart
+	}
+}
+
+func (cb *circuitBreaker) notify(host string, from, to CircuitState) {
+	if from != to && cb.opts.OnStateChange != nil {
+		cb.opts.OnStateChange(host, from, to)
+	}
+}
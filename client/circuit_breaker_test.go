@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pokt-foundation/utils-go/mock-client"
+	"github.com/stretchr/testify/require"
+)
+
+type stateChange struct {
+	host     string
+	from, to CircuitState
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	c := require.New(t)
+
+	var changes []stateChange
+
+	breaker := newCircuitBreaker(CircuitBreakerOpts{
+		FailureRatio:     0.5,
+		MinRequests:      4,
+		Window:           time.Minute,
+		Cooldown:         10 * time.Second,
+		HalfOpenRequests: 2,
+		OnStateChange: func(host string, from, to CircuitState) {
+			changes = append(changes, stateChange{host: host, from: from, to: to})
+		},
+	})
+
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	allow := func(host string) uint64 {
+		generation, err := breaker.allow(host)
+		c.NoError(err)
+
+		return generation
+	}
+
+	rejected := func() {
+		_, err := breaker.allow("dummy.com")
+		c.ErrorIs(err, ErrCircuitOpen)
+	}
+
+	send := func(result circuitResult) {
+		breaker.done("dummy.com", allow("dummy.com"), result)
+	}
+
+	// Failures below the min requests don't open the circuit
+	send(circuitFailure)
+	send(circuitFailure)
+	send(circuitSuccess)
+	c.Equal(CircuitClosed, breaker.state("dummy.com"))
+
+	// Counts are reset after the window
+	now = now.Add(time.Minute)
+	send(circuitFailure)
+	send(circuitSuccess)
+	send(circuitSuccess)
+	send(circuitIgnored)
+	c.Equal(CircuitClosed, breaker.state("dummy.com"))
+
+	// A request sent while the circuit is closed and still in flight once it opens
+	stale := allow("dummy.com")
+
+	send(circuitFailure)
+	c.Equal(CircuitOpen, breaker.state("dummy.com"))
+	rejected()
+
+	// Other hosts are not affected
+	breaker.done("other.com", allow("other.com"), circuitSuccess)
+
+	// After the cooldown just the half open requests are allowed, the stale request doesn't take a slot
+	now = now.Add(10 * time.Second)
+	first := allow("dummy.com")
+	c.Equal(CircuitHalfOpen, breaker.state("dummy.com"))
+	second := allow("dummy.com")
+	rejected()
+
+	// The stale request doesn't close the circuit
+	breaker.done("dummy.com", stale, circuitSuccess)
+	breaker.done("dummy.com", first, circuitSuccess)
+	c.Equal(CircuitHalfOpen, breaker.state("dummy.com"))
+
+	// A failed trial opens the circuit again
+	breaker.done("dummy.com", second, circuitFailure)
+	c.Equal(CircuitOpen, breaker.state("dummy.com"))
+
+	now = now.Add(10 * time.Second)
+	send(circuitSuccess)
+	c.Equal(CircuitHalfOpen, breaker.state("dummy.com"))
+	send(circuitSuccess)
+	c.Equal(CircuitClosed, breaker.state("dummy.com"))
+
+	c.Equal([]stateChange{
+		{host: "dummy.com", from: CircuitClosed, to: CircuitOpen},
+		{host: "dummy.com", from: CircuitOpen, to: CircuitHalfOpen},
+		{host: "dummy.com", from: CircuitHalfOpen, to: CircuitOpen},
+		{host: "dummy.com", from: CircuitOpen, to: CircuitHalfOpen},
+		{host: "dummy.com", from: CircuitHalfOpen, to: CircuitClosed},
+	}, changes)
+}
+
+func TestCircuitResultFor(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(circuitSuccess, circuitResultFor(&http.Response{StatusCode: http.StatusOK}, nil))
+	c.Equal(circuitSuccess, circuitResultFor(&http.Response{StatusCode: http.StatusNotFound}, nil))
+	c.Equal(circuitFailure, circuitResultFor(&http.Response{StatusCode: http.StatusBadGateway}, nil))
+	c.Equal(circuitFailure, circuitResultFor(nil, errors.New("dummy")))
+	c.Equal(circuitIgnored, circuitResultFor(nil, &url.Error{Op: "Get", URL: "https://dummy.com", Err: context.Canceled}))
+}
+
+func TestCircuitState_String(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal("closed", CircuitClosed.String())
+	c.Equal("open", CircuitOpen.String())
+	c.Equal("half-open", CircuitHalfOpen.String())
+	c.Equal("unknown", CircuitState(10).String())
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	c := require.New(t)
+
+	var changes []stateChange
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries: 5,
+		Timeout: time.Second,
+		Backoff: ConstantBackoff(time.Millisecond),
+		CircuitBreaker: &CircuitBreakerOpts{
+			MinRequests: 2,
+			Cooldown:    time.Minute,
+			OnStateChange: func(host string, from, to CircuitState) {
+				changes = append(changes, stateChange{host: host, from: from, to: to})
+			},
+		},
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodGet, "https://dummy.com", http.StatusInternalServerError, `{"not_ok": 1}`)
+
+	c.Equal(CircuitClosed, client.CircuitState("dummy.com"))
+
+	response, err := client.GetWithURLAndParams("https://dummy.com", url.Values{}, http.Header{})
+	c.ErrorIs(err, ErrCircuitOpen)
+	c.Nil(response)
+	c.Equal(2, httpmock.GetTotalCallCount())
+	c.Equal(CircuitOpen, client.CircuitState("dummy.com"))
+	c.Equal([]stateChange{{host: "dummy.com", from: CircuitClosed, to: CircuitOpen}}, changes)
+
+	c.Equal(CircuitClosed, NewDefaultClient().CircuitState("dummy.com"))
+}
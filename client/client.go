@@ -6,61 +6,208 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
+	"slices"
 	"time"
 )
 
 const (
 	defaultHTTPClientTimeout = 5 * time.Second
 	defaultHTTPClientRetries = 0
-	backoffTime              = 2 * time.Second
+	defaultBackoffTime       = 2 * time.Second
 )
 
 // Client is a wrapper for the default client
 type Client struct {
-	Client  *http.Client
-	retries int
+	Client          *http.Client
+	retries         int
+	backoff         BackoffStrategy
+	retryPolicy     RetryPolicy
+	maxRetryAfter   time.Duration
+	maxResponseSize int64
+	breaker         *circuitBreaker
+	limiter         *rateLimiter
+	transport       http.RoundTripper
+	middlewares     []Middleware
+	requestLog      *RequestLogOpts
+	baseURL         string
+	defaultHeaders  http.Header
+	defaultQuery    url.Values
+	cache           CacheStorage
+	coalescer       *coalescer
+	metrics         MetricsRecorder
+	tracer          Tracer
+	attemptTimeout  time.Duration
+	totalTimeout    time.Duration
+	decompression   *DecompressionOpts
 }
 
 // NewDefaultClient returns httpclient instance with default config
 func NewDefaultClient() *Client {
-	return &Client{
-		Client: &http.Client{
-			Timeout: defaultHTTPClientTimeout,
-		},
-		retries: defaultHTTPClientRetries,
-	}
+	return NewCustomClientWithOptions(CustomClientOpts{
+		Retries: defaultHTTPClientRetries,
+		Timeout: defaultHTTPClientTimeout,
+	})
 }
 
 // NewCustomClient returns httpclient instance with given custom config
 func NewCustomClient(retries int, timeout time.Duration) *Client {
-	return &Client{
-		Client: &http.Client{
-			Timeout: timeout,
-		},
-		retries: retries,
-	}
+	return NewCustomClientWithOptions(CustomClientOpts{
+		Retries: retries,
+		Timeout: timeout,
+	})
 }
 
-// CustomClientOpts
+// CustomClientOpts are the options to build a custom client with NewCustomClientWithOptions
 type CustomClientOpts struct {
-	Retries   int
-	Timeout   time.Duration
-	Transport http.RoundTripper
+	Retries int
+	// Timeout is the http.Client timeout, it applies to every attempt on its own
+	Timeout time.Duration
+	// AttemptTimeout is the max time of every attempt, including reading its body, and the time budgeted
+	// for the next attempt when deciding if a request can be retried before its deadline
+	AttemptTimeout time.Duration
+	// TotalTimeout is the max time of a request, including all its attempts and the waits between them
+	// requests are not retried when the wait plus another attempt would go past it, the last response is returned instead
+	TotalTimeout time.Duration
+	Transport    http.RoundTripper
+	// TransportOpts builds a transport tuned with them when no Transport is given, see NewTransport
+	// without any of them the client uses http.DefaultTransport
+	TransportOpts *TransportOpts
+	// Backoff is the wait strategy between retries, defaults to a constant 2 seconds
+	Backoff BackoffStrategy
+	// RetryPolicy decides which attempts are retried, defaults to DefaultRetryPolicy
+	RetryPolicy RetryPolicy
+	// MaxRetryAfter is the longest Retry-After wait honored, longer waits return the response right away
+	// zero means no limit
+	MaxRetryAfter time.Duration
+	// MaxResponseSize is the max body size read from the responses, reading past it fails with a *BodyTooLargeError
+	// the JSON helpers default to 10MB, the responses returned as they are are only limited when it's set
+	// and streams apply it to every line or event
+	MaxResponseSize int64
+	// Decompression makes the client ask for compressed responses and decompress them when set,
+	// guarding against decompression bombs, see DecompressionMiddleware
+	Decompression *DecompressionOpts
+	// CircuitBreaker enables a circuit breaker per host when set
+	CircuitBreaker *CircuitBreakerOpts
+	// RateLimit limits the requests sent to all hosts when set
+	RateLimit *RateLimit
+	// HostRateLimits limits the requests sent to each host, keyed by host (with port if not the default)
+	HostRateLimits map[string]RateLimit
+	// AdaptiveRateLimit pauses the requests to a host when its X-RateLimit-Remaining header reaches zero
+	// or it answers 429 with a Retry-After header
+	AdaptiveRateLimit bool
+	// Middlewares wrap the transport in order, the first one is the first to see each request
+	Middlewares []Middleware
+	// RequestLog enables logging every request sent and every retry when set
+	RequestLog *RequestLogOpts
+	// BaseURL is joined with the relative URLs given to the request helpers and builder
+	BaseURL string
+	// DefaultHeaders are set on every request that doesn't have them already
+	DefaultHeaders http.Header
+	// DefaultQueryParams are set on every request that doesn't have them already
+	DefaultQueryParams url.Values
+	// Cache enables caching the responses of GET requests on the given storage, see CacheMiddleware
+	Cache CacheStorage
+	// CoalesceRequests makes identical GET and HEAD requests in flight at the same time share a single
+	// upstream call, each caller gets its own copy of the response with the body read up to MaxResponseSize
+	CoalesceRequests bool
+	// CoalesceHeaders are the request headers that must match, on top of the method, URL,
+	// Authorization and Cookie, for requests to be coalesced
+	CoalesceHeaders []string
+	// Metrics receives the metrics of every request, see PrometheusMetrics for a default implementation
+	Metrics MetricsRecorder
+	// Tracer starts a span for every attempt, with the timings of its DNS, connect and TLS phases
+	// the W3C trace context set on the request context with ContextWithTraceContext is always propagated
+	Tracer Tracer
 }
 
 // NewCustomClientWithOptions returns httpclient instance with given custom config in the opts struct
 func NewCustomClientWithOptions(opts CustomClientOpts) *Client {
-	return &Client{
+	backoff := opts.Backoff
+	if backoff == nil {
+		backoff = ConstantBackoff(defaultBackoffTime)
+	}
+
+	retryPolicy := opts.RetryPolicy
+	if retryPolicy == nil {
+		retryPolicy = DefaultRetryPolicy
+	}
+
+	var breaker *circuitBreaker
+	if opts.CircuitBreaker != nil {
+		breaker = newCircuitBreaker(*opts.CircuitBreaker)
+	}
+
+	var limiter *rateLimiter
+	if opts.RateLimit != nil || len(opts.HostRateLimits) != 0 || opts.AdaptiveRateLimit {
+		limiter = newRateLimiter(opts.RateLimit, opts.HostRateLimits, opts.AdaptiveRateLimit)
+	}
+
+	var requestLog *RequestLogOpts
+	if opts.RequestLog != nil {
+		logOpts := opts.RequestLog.withDefaults()
+		requestLog = &logOpts
+	}
+
+	client := &Client{
 		Client: &http.Client{
-			Timeout:   opts.Timeout,
-			Transport: opts.Transport,
+			Timeout: opts.Timeout,
 		},
-		retries: opts.Retries,
+		retries:         max(opts.Retries, 0),
+		backoff:         backoff,
+		retryPolicy:     retryPolicy,
+		maxRetryAfter:   opts.MaxRetryAfter,
+		maxResponseSize: opts.MaxResponseSize,
+		breaker:         breaker,
+		limiter:         limiter,
+		transport:       clientTransport(opts.Transport, opts.TransportOpts),
+		middlewares:     slices.Clone(opts.Middlewares),
+		requestLog:      requestLog,
+		baseURL:         opts.BaseURL,
+		defaultHeaders:  canonicalHeaders(opts.DefaultHeaders),
+		defaultQuery:    cloneValues(opts.DefaultQueryParams),
+		cache:           opts.Cache,
+		metrics:         opts.Metrics,
+		tracer:          opts.Tracer,
+		attemptTimeout:  opts.AttemptTimeout,
+		totalTimeout:    opts.TotalTimeout,
+		decompression:   opts.Decompression,
 	}
+
+	if opts.CoalesceRequests {
+		client.coalescer = newCoalescer(opts.CoalesceHeaders, opts.MaxResponseSize)
+	}
+
+	client.Client.Transport = client.chainTransport()
+
+	return client
+}
+
+// chainTransport returns the client transport wrapped by its middlewares
+// the internal ones like request logging are the innermost, so they see the final request
+// and the cache goes before the logging so just the requests actually sent are logged
+// the decompression is the closest to the transport, so the others see the decompressed responses
+func (c *Client) chainTransport() http.RoundTripper {
+	middlewares := slices.Clone(c.middlewares)
+	if c.cache != nil {
+		middlewares = append(middlewares, CacheMiddleware(c.cache))
+	}
+	if c.requestLog != nil {
+		middlewares = append(middlewares, LoggingMiddleware(*c.requestLog))
+	}
+	if c.decompression != nil {
+		middlewares = append(middlewares, DecompressionMiddleware(*c.decompression))
+	}
+
+	if len(middlewares) == 0 {
+		return c.transport
+	}
+
+	return Chain(c.transport, middlewares...)
 }
 
 func getJSONBodyFromParams(params any) (io.Reader, error) {
@@ -73,89 +220,83 @@ func getJSONBodyFromParams(params any) (io.Reader, error) {
 		return nil, err
 	}
 
-	return bytes.NewBufferString(string(rawBody)), nil
+	return bytes.NewReader(rawBody), nil
 }
 
-// PostWithURLJSONParams does post request with JSON param
+// PostWithURLJSONParams does post request with JSON param, the Content-Type is always application/json
 func (c *Client) PostWithURLJSONParams(url string, params any, headers http.Header) (*http.Response, error) {
 	return c.PostWithURLJSONParamsWithCtx(context.Background(), url, params, headers)
 }
 
-// PostWithURLJSONParamsWithCtx does post request with JSON param
+// PostWithURLJSONParamsWithCtx does post request with JSON param, the Content-Type is always application/json
 func (c *Client) PostWithURLJSONParamsWithCtx(ctx context.Context, url string, params any, headers http.Header) (*http.Response, error) {
-	body, err := getJSONBodyFromParams(params)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
-	if err != nil {
-		return nil, err
-	}
-
-	headers.Set("Content-Type", "application/json")
-
-	req.Header = headers
-
-	return c.DoRequestWithRetries(req)
+	return c.NewRequest(http.MethodPost, url).Headers(headers).JSONBody(params).Header("Content-Type", contentTypeJSON).Do(ctx)
 }
 
-// PostWithURLEncodedParams does post request with URL encoded params
+// PostWithURLEncodedParams does post request with URL encoded params, the Content-Type is always
+// application/x-www-form-urlencoded
 func (c *Client) PostWithURLEncodedParams(url string, params url.Values, headers http.Header) (*http.Response, error) {
 	return c.PostWithURLEncodedParamsWithCtx(context.Background(), url, params, headers)
 }
 
-// PostWithURLEncodedParamsWithCtx does post request with URL encoded params
+// PostWithURLEncodedParamsWithCtx does post request with URL encoded params, the Content-Type is always
+// application/x-www-form-urlencoded
 func (c *Client) PostWithURLEncodedParamsWithCtx(ctx context.Context, url string, params url.Values, headers http.Header) (*http.Response, error) {
-	var body io.Reader
-
-	if len(params) != 0 {
-		body = strings.NewReader(params.Encode())
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
-	if err != nil {
-		return nil, err
-	}
-
-	headers.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	req.Header = headers
-
-	return c.DoRequestWithRetries(req)
+	return c.NewRequest(http.MethodPost, url).Headers(headers).FormBody(params).Header("Content-Type", contentTypeForm).Do(ctx)
 }
 
-// PutWithURLJSONParams does post request with JSON param
+// PutWithURLJSONParams does put request with JSON param, the Content-Type is always application/json
 func (c *Client) PutWithURLJSONParams(url string, params any, headers http.Header) (*http.Response, error) {
 	return c.PutWithURLJSONParamsWithCtx(context.Background(), url, params, headers)
 }
 
-// PutWithURLJSONParamsWithCtx does post request with JSON param
+// PutWithURLJSONParamsWithCtx does put request with JSON param, the Content-Type is always application/json
 func (c *Client) PutWithURLJSONParamsWithCtx(ctx context.Context, url string, params any, headers http.Header) (*http.Response, error) {
-	body, err := getJSONBodyFromParams(params)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
-	if err != nil {
-		return nil, err
-	}
-
-	headers.Set("Content-Type", "application/json")
+	return c.NewRequest(http.MethodPut, url).Headers(headers).JSONBody(params).Header("Content-Type", contentTypeJSON).Do(ctx)
+}
 
-	req.Header = headers
+// PatchWithURLJSONParams does patch request with JSON param, the Content-Type is always application/json
+func (c *Client) PatchWithURLJSONParams(url string, params any, headers http.Header) (*http.Response, error) {
+	return c.PatchWithURLJSONParamsWithCtx(context.Background(), url, params, headers)
+}
 
-	return c.DoRequestWithRetries(req)
+// PatchWithURLJSONParamsWithCtx does patch request with JSON param, the Content-Type is always application/json
+func (c *Client) PatchWithURLJSONParamsWithCtx(ctx context.Context, url string, params any, headers http.Header) (*http.Response, error) {
+	return c.NewRequest(http.MethodPatch, url).Headers(headers).JSONBody(params).Header("Content-Type", contentTypeJSON).Do(ctx)
 }
 
-// GetWithURLAndParams does get request with url values as params
+// GetWithURLAndParams does get request with url values as params, they replace the query of the URL
 func (c *Client) GetWithURLAndParams(rawURL string, params url.Values, headers http.Header) (*http.Response, error) {
 	return c.GetWithURLAndParamsWithCtx(context.Background(), rawURL, params, headers)
 }
 
-// GetWithURLAndParamsWithCtx does get request with url values as params
+// GetWithURLAndParamsWithCtx does get request with url values as params, they replace the query of the URL
 func (c *Client) GetWithURLAndParamsWithCtx(ctx context.Context, rawURL string, params url.Values, headers http.Header) (*http.Response, error) {
+	return c.doWithQuery(ctx, http.MethodGet, rawURL, params, headers)
+}
+
+// HeadWithURLAndParams does head request with url values as params, they replace the query of the URL
+func (c *Client) HeadWithURLAndParams(rawURL string, params url.Values, headers http.Header) (*http.Response, error) {
+	return c.HeadWithURLAndParamsWithCtx(context.Background(), rawURL, params, headers)
+}
+
+// HeadWithURLAndParamsWithCtx does head request with url values as params, they replace the query of the URL
+func (c *Client) HeadWithURLAndParamsWithCtx(ctx context.Context, rawURL string, params url.Values, headers http.Header) (*http.Response, error) {
+	return c.doWithQuery(ctx, http.MethodHead, rawURL, params, headers)
+}
+
+// DeleteWithURLAndParams does delete request with url values as params, they replace the query of the URL
+func (c *Client) DeleteWithURLAndParams(rawURL string, params url.Values, headers http.Header) (*http.Response, error) {
+	return c.DeleteWithURLAndParamsWithCtx(context.Background(), rawURL, params, headers)
+}
+
+// DeleteWithURLAndParamsWithCtx does delete request with url values as params, they replace the query of the URL
+func (c *Client) DeleteWithURLAndParamsWithCtx(ctx context.Context, rawURL string, params url.Values, headers http.Header) (*http.Response, error) {
+	return c.doWithQuery(ctx, http.MethodDelete, rawURL, params, headers)
+}
+
+// doWithQuery sends a request without body to the URL with its query replaced by the params
+func (c *Client) doWithQuery(ctx context.Context, method, rawURL string, params url.Values, headers http.Header) (*http.Response, error) {
 	urlStruct, err := url.Parse(rawURL)
 	if err != nil {
 		return nil, err
@@ -163,41 +304,153 @@ func (c *Client) GetWithURLAndParamsWithCtx(ctx context.Context, rawURL string,
 
 	urlStruct.RawQuery = params.Encode()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStruct.String(), nil)
-	if err != nil {
-		return nil, err
+	return c.NewRequest(method, urlStruct.String()).Headers(headers).Do(ctx)
+}
+
+// DoRequestWithRetries does requests with the retries set on client and backoff strategy
+// the retry policy decides which responses and errors are retried (5xx, 429 and transient errors by default)
+// the Retry-After header is honored and the wait between attempts is cut short if the request context is done
+// request bodies are buffered if needed so every attempt sends the full payload
+// the client default headers and query params are added to the request if it doesn't have them
+// with request coalescing enabled, identical GET and HEAD requests in flight share the same upstream call
+// with a circuit breaker set, requests to a host with an open circuit fail right away with ErrCircuitOpen
+// with rate limits set, every attempt waits for its turn or until the request context is done
+// with a cache set, fresh cached responses are served without waiting for the rate limit or the circuit breaker
+func (c *Client) DoRequestWithRetries(req *http.Request) (*http.Response, error) {
+	req = c.withDefaults(req)
+
+	if c.coalescer != nil && coalescable(req) {
+		return c.coalescer.do(req, c.doRequestWithRetries)
 	}
 
-	req.Header = headers
+	return c.doRequestWithRetries(req)
+}
+
+// doRequestWithRetries sends the request until it succeeds or it's out of attempts
+func (c *Client) doRequestWithRetries(req *http.Request) (*http.Response, error) {
+	req, deadline, cancel := c.withTotalTimeout(req)
+	start := time.Now()
+
+	resp, attempts, err := c.sendOrServeCached(req, deadline)
+	if c.metrics != nil {
+		c.metrics.ObserveRequest(newRequestMetrics(req, resp, attempts, time.Since(start), err))
+	}
 
-	return c.DoRequestWithRetries(req)
+	return c.limitBody(withCancelOnClose(resp, cancel)), err
 }
 
-// DoRequestWithRetries does requests with the retries set on client and backoff time
-// just retries request with status code 5xx
-func (c *Client) DoRequestWithRetries(req *http.Request) (*http.Response, error) {
-	var resp *http.Response
-	var err error
+// sendOrServeCached serves fresh cached responses right away, so they don't wait for the rate limiter
+// nor go through the circuit breaker, the other requests are sent with the retry loop
+func (c *Client) sendOrServeCached(req *http.Request, deadline time.Time) (*http.Response, int, error) {
+	if c.cache != nil {
+		if resp, ok := lookupCache(c.cache, req, time.Now()); ok {
+			return resp, 0, nil
+		}
+	}
+
+	return c.sendWithRetries(req, deadline)
+}
 
+// sendWithRetries runs the retry loop until the deadline, returning the amount of attempts made
+func (c *Client) sendWithRetries(req *http.Request, deadline time.Time) (*http.Response, int, error) {
 	// at least one attempt is made, regardless of how many retries were on config
 	attempts := c.retries + 1
 
-	for i := 0; i < attempts; i++ {
-		resp, err = c.Client.Do(req)
-		if err != nil {
-			return nil, err
+	if attempts > 1 {
+		if err := BufferRequestBody(req); err != nil {
+			return nil, 0, err
 		}
+	}
 
-		// Just retry 5xx responses
-		if string(resp.Status[0]) != "5" {
-			break
-		}
+	var delay time.Duration
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.doAttempt(req, attempt)
 
 		// On the last attempt there's no reason to wait the backoff time
-		if i != attempts-1 {
-			time.Sleep(backoffTime)
+		if attempt >= attempts || !c.shouldRetry(req, resp, err) {
+			return resp, attempt, err
+		}
+
+		var ok bool
+
+		delay, ok = c.nextDelay(attempt, delay, resp)
+		if !ok || !c.fitsAnotherAttempt(deadline, delay) {
+			return resp, attempt, err
+		}
+
+		if resp != nil {
+			drainAndClose(resp.Body)
+		}
+
+		c.logRetry(req, attempt, delay, resp, err)
+
+		if err := sleepWithContext(req.Context(), delay); err != nil {
+			return nil, attempt, err
 		}
 	}
+}
+
+// doAttempt sends a single attempt of the request
+func (c *Client) doAttempt(req *http.Request, attempt int) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	attemptReq, err := requestForAttempt(req, attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.limiter == nil {
+		return c.sendWithTimeout(attemptReq)
+	}
+
+	host := attemptReq.URL.Host
+	if err := c.limiter.wait(attemptReq.Context(), host); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.sendWithTimeout(attemptReq)
+	c.limiter.observe(host, resp)
+
+	return resp, err
+}
+
+// send does the request through the circuit breaker of its host if there is one
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.breaker == nil {
+		return c.httpClient(req).Do(req)
+	}
+
+	host := req.URL.Host
+	generation, err := c.breaker.allow(host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, host)
+	}
+
+	resp, err := c.httpClient(req).Do(req)
+	c.breaker.done(host, generation, circuitResultFor(resp, err))
+
+	return resp, err
+}
+
+// shouldRetry checks if the attempt can be retried, requests with a done context
+// or rejected by the circuit breaker are never retried
+func (c *Client) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	return c.retryPolicy(req, resp, err)
+}
+
+// CircuitState returns the circuit breaker state for the given host
+// it is always closed if the client has no circuit breaker
+func (c *Client) CircuitState(host string) CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
 
-	return resp, nil
+	return c.breaker.state(host)
 }
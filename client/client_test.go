@@ -272,3 +272,243 @@ func TestClient_DoRequestWithRetries(t *testing.T) {
 	c.Equal(http.StatusOK, response.StatusCode)
 	c.NoError(response.Body.Close())
 }
+
+func TestClient_DoRequestWithRetriesBackoff(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries: 2,
+		Timeout: time.Second,
+		Backoff: ConstantBackoff(time.Millisecond),
+	})
+	c.NotEmpty(client)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMultipleMockedPlainResponses(http.MethodGet, "https://dummy.com", []int{
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusOK,
+	}, []string{
+		`{"not_ok": 1}`,
+		`{"not_ok": 2}`,
+		`{"ok": 3}`,
+	})
+
+	start := time.Now()
+
+	response, err := client.GetWithURLAndParams("https://dummy.com", url.Values{}, http.Header{})
+	c.NoError(err)
+
+	c.Equal(http.StatusOK, response.StatusCode)
+	c.NoError(response.Body.Close())
+	c.Less(time.Since(start), time.Second)
+	c.Equal(3, httpmock.GetTotalCallCount())
+}
+
+func TestClient_DoRequestWithRetriesNegativeRetries(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries: -1,
+		Timeout: time.Second,
+		Backoff: ConstantBackoff(time.Millisecond),
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodGet, "https://dummy.com", http.StatusInternalServerError, `{"not_ok": 1}`)
+
+	// A single attempt is made, even if the retries are set after the client is built
+	for _, retries := range []int{-1, -5} {
+		client.retries = retries
+
+		response, err := client.GetWithURLAndParams("https://dummy.com", url.Values{}, http.Header{})
+		c.NoError(err)
+		c.Equal(http.StatusInternalServerError, response.StatusCode)
+		c.NoError(response.Body.Close())
+	}
+
+	c.Equal(2, httpmock.GetTotalCallCount())
+}
+
+func TestClient_DoRequestWithRetriesContextCancelled(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries: 2,
+		Timeout: time.Second,
+		Backoff: ConstantBackoff(time.Minute),
+	})
+	c.NotEmpty(client)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodGet, "https://dummy.com", http.StatusInternalServerError, `{"not_ok": 1}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	response, err := client.GetWithURLAndParamsWithCtx(ctx, "https://dummy.com", url.Values{}, http.Header{})
+	c.ErrorIs(err, context.DeadlineExceeded)
+	c.Nil(response)
+	c.Equal(1, httpmock.GetTotalCallCount())
+}
+
+func TestClient_PatchWithURLJSONParams(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+	c.NotEmpty(client)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponseFromFile(http.MethodPatch, "https://dummy.com", http.StatusOK, "../mock-client/samples/dummy.json")
+
+	response, err := client.PatchWithURLJSONParams("https://dummy.com", map[string]string{
+		"ohana": "family",
+	}, nil)
+	c.NoError(err)
+
+	c.NotEmpty(response)
+	c.Equal(http.StatusOK, response.StatusCode)
+	c.NoError(response.Body.Close())
+
+	response, err = client.PatchWithURLJSONParamsWithCtx(context.Background(), "https://dummy.com", nil, http.Header{})
+	c.NoError(err)
+
+	c.NotEmpty(response)
+	c.Equal(http.StatusOK, response.StatusCode)
+	c.NoError(response.Body.Close())
+}
+
+func TestClient_DeleteWithURLAndParams(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+	c.NotEmpty(client)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodDelete, "https://dummy.com", http.StatusNoContent, "")
+
+	params := url.Values{}
+	params.Set("family", "ohana")
+
+	response, err := client.DeleteWithURLAndParams("https://dummy.com", params, nil)
+	c.NoError(err)
+
+	c.NotEmpty(response)
+	c.Equal(http.StatusNoContent, response.StatusCode)
+	c.NoError(response.Body.Close())
+
+	response, err = client.DeleteWithURLAndParamsWithCtx(context.Background(), "https://dummy.com", params, http.Header{})
+	c.NoError(err)
+
+	c.NotEmpty(response)
+	c.Equal(http.StatusNoContent, response.StatusCode)
+	c.NoError(response.Body.Close())
+}
+
+func TestClient_HeadWithURLAndParams(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+	c.NotEmpty(client)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodHead, "https://dummy.com", http.StatusOK, "")
+
+	response, err := client.HeadWithURLAndParams("https://dummy.com", url.Values{}, nil)
+	c.NoError(err)
+
+	c.NotEmpty(response)
+	c.Equal(http.StatusOK, response.StatusCode)
+	c.NoError(response.Body.Close())
+
+	response, err = client.HeadWithURLAndParamsWithCtx(context.Background(), "https://dummy.com", url.Values{}, http.Header{})
+	c.NoError(err)
+
+	c.NotEmpty(response)
+	c.Equal(http.StatusOK, response.StatusCode)
+	c.NoError(response.Body.Close())
+}
+
+func TestClient_HelpersDoNotModifyHeaders(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+	c.NotEmpty(client)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodPost, "https://dummy.com", http.StatusOK, "")
+	mock.AddMockedResponse(http.MethodPut, "https://dummy.com", http.StatusOK, "")
+
+	headers := http.Header{"X-Dummy": []string{"ohana"}}
+
+	response, err := client.PostWithURLJSONParams("https://dummy.com", nil, headers)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	response, err = client.PostWithURLEncodedParams("https://dummy.com", nil, headers)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	response, err = client.PutWithURLJSONParams("https://dummy.com", nil, nil)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	c.Equal(http.Header{"X-Dummy": []string{"ohana"}}, headers)
+}
+
+func TestClient_HelpersReplaceQueryAndForceContentType(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+	c.NotEmpty(client)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var req *http.Request
+	responder := func(r *http.Request) (*http.Response, error) {
+		req = r
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	}
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com", responder)
+	httpmock.RegisterResponder(http.MethodDelete, "https://dummy.com", responder)
+	httpmock.RegisterResponder(http.MethodPost, "https://dummy.com", responder)
+
+	headers := http.Header{"Content-Type": []string{"text/plain"}}
+
+	response, err := client.GetWithURLAndParams("https://dummy.com?a=1&b=1", url.Values{"a": []string{"2"}}, nil)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+	c.Equal("a=2", req.URL.RawQuery)
+
+	response, err = client.DeleteWithURLAndParams("https://dummy.com?a=1", nil, nil)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+	c.Empty(req.URL.RawQuery)
+
+	response, err = client.PostWithURLJSONParams("https://dummy.com", nil, headers)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+	c.Equal("application/json", req.Header.Get("Content-Type"))
+
+	response, err = client.PostWithURLEncodedParams("https://dummy.com", nil, headers)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+	c.Equal("application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+
+	c.Equal(http.Header{"Content-Type": []string{"text/plain"}}, headers)
+}
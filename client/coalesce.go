@@ -0,0 +1,149 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// credentialHeaders always take part of the key, so callers with different credentials never share a response
+var credentialHeaders = []string{"Authorization", "Cookie"}
+
+// coalescer shares a single upstream call between all the identical requests in flight
+type coalescer struct {
+	mu      sync.Mutex
+	calls   map[string]*coalescedCall
+	headers []string
+	maxSize int64
+}
+
+type coalescedCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	resp    *http.Response
+	body    []byte
+	err     error
+}
+
+func newCoalescer(headers []string, maxSize int64) *coalescer {
+	canonical := slices.Clone(credentialHeaders)
+	for _, header := range headers {
+		header = http.CanonicalHeaderKey(header)
+		if !slices.Contains(canonical, header) {
+			canonical = append(canonical, header)
+		}
+	}
+
+	return &coalescer{
+		calls:   make(map[string]*coalescedCall),
+		headers: canonical,
+		maxSize: maxSize,
+	}
+}
+
+// coalescable checks if the request can share its response with others, just GET and HEAD requests can
+func coalescable(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+// do joins the call in flight for the same request or starts a new one with send
+// the call goes on while there is at least one caller waiting for it and every caller
+// gets its own copy of the response
+func (co *coalescer) do(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	key := co.key(req)
+
+	co.mu.Lock()
+	call, ok := co.calls[key]
+	if !ok {
+		// The call must not end when the caller that started it leaves, just when all of them do
+		ctx, cancel := context.WithCancel(context.WithoutCancel(req.Context()))
+		call = &coalescedCall{done: make(chan struct{}), cancel: cancel}
+		co.calls[key] = call
+
+		go co.run(key, call, req.WithContext(ctx), send)
+	}
+	call.waiters++
+	co.mu.Unlock()
+
+	select {
+	case <-call.done:
+		co.leave(key, call)
+		return call.response(req)
+	case <-req.Context().Done():
+		co.leave(key, call)
+		return nil, req.Context().Err()
+	}
+}
+
+func (co *coalescer) run(key string, call *coalescedCall, req *http.Request, send func(*http.Request) (*http.Response, error)) {
+	defer call.cancel()
+
+	call.resp, call.err = send(req)
+	if call.err == nil {
+		call.body, call.err = readBody(call.resp.Body, co.maxSize)
+		_ = call.resp.Body.Close()
+	}
+
+	co.mu.Lock()
+	if co.calls[key] == call {
+		delete(co.calls, key)
+	}
+	co.mu.Unlock()
+
+	close(call.done)
+}
+
+// leave removes a caller from the call, cancelling it if nobody is waiting for it anymore
+func (co *coalescer) leave(key string, call *coalescedCall) {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+
+	if co.calls[key] == call {
+		delete(co.calls, key)
+	}
+
+	call.cancel()
+}
+
+// key identifies the request by method, URL, credentials and the selected headers
+func (co *coalescer) key(req *http.Request) string {
+	var key strings.Builder
+
+	key.WriteString(req.Method)
+	key.WriteString(" ")
+	key.WriteString(req.URL.String())
+
+	for _, header := range co.headers {
+		key.WriteString("\n")
+		key.WriteString(header)
+		key.WriteString(": ")
+		key.WriteString(strings.Join(req.Header.Values(header), ","))
+	}
+
+	return key.String()
+}
+
+// response returns a copy of the shared response with its own body
+func (call *coalescedCall) response(req *http.Request) (*http.Response, error) {
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	resp := *call.resp
+	resp.Header = call.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(call.body))
+	resp.ContentLength = int64(len(call.body))
+	resp.Request = req
+
+	return &resp, nil
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CoalesceRequests(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		CoalesceRequests: true,
+		CoalesceHeaders:  []string{"x-api-key"},
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var calls atomic.Int32
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/block", func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"block": 10}`), nil
+	})
+
+	const callers = 10
+
+	bodies := make([]string, callers*2)
+
+	var wg sync.WaitGroup
+
+	for i := range bodies {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			// Half the callers use another key, so they must not share the response with the others
+			key := "first"
+			if i%2 == 1 {
+				key = "second"
+			}
+
+			response, err := client.GetWithURLAndParams("https://dummy.com/block", nil, http.Header{"X-Api-Key": []string{key}})
+			c.NoError(err)
+
+			body, err := io.ReadAll(response.Body)
+			c.NoError(err)
+			c.NoError(response.Body.Close())
+
+			bodies[i] = string(body)
+		}(i)
+	}
+
+	wg.Wait()
+
+	c.Equal(int32(2), calls.Load())
+
+	for _, body := range bodies {
+		c.Equal(`{"block": 10}`, body)
+	}
+
+	// Requests are not coalesced once the call is done
+	response, err := client.GetWithURLAndParams("https://dummy.com/block", nil, http.Header{"X-Api-Key": []string{"first"}})
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+	c.Equal(int32(3), calls.Load())
+
+	// POST requests are never coalesced
+	c.False(coalescable(&http.Request{Method: http.MethodPost}))
+}
+
+func TestClient_CoalesceRequestsCancel(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{CoalesceRequests: true})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var cancelled atomic.Bool
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/slow", func(req *http.Request) (*http.Response, error) {
+		select {
+		case <-req.Context().Done():
+			cancelled.Store(true)
+			return nil, req.Context().Err()
+		case <-time.After(100 * time.Millisecond):
+			return httpmock.NewStringResponse(http.StatusOK, `{"ok": 1}`), nil
+		}
+	})
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+
+	firstErr := make(chan error, 1)
+
+	go func() {
+		_, err := client.GetWithURLAndParamsWithCtx(firstCtx, "https://dummy.com/slow", nil, nil)
+		firstErr <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	secondDone := make(chan struct{})
+
+	go func() {
+		defer close(secondDone)
+
+		response, err := client.GetWithURLAndParams("https://dummy.com/slow", nil, nil)
+		c.NoError(err)
+		c.NoError(response.Body.Close())
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	// The caller that started the call leaving must not cancel it for the others
+	cancelFirst()
+	c.ErrorIs(<-firstErr, context.Canceled)
+
+	<-secondDone
+	c.False(cancelled.Load())
+
+	// The call is cancelled once every caller leaves
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetWithURLAndParamsWithCtx(ctx, "https://dummy.com/slow", nil, nil)
+	c.ErrorIs(err, context.DeadlineExceeded)
+	c.Eventually(cancelled.Load, time.Second, 10*time.Millisecond)
+}
+
+func TestCoalescer_KeyCredentials(t *testing.T) {
+	c := require.New(t)
+
+	co := newCoalescer([]string{"authorization", "x-api-key"}, 0)
+	c.Equal([]string{"Authorization", "Cookie", "X-Api-Key"}, co.headers)
+
+	co = newCoalescer(nil, 0)
+
+	keys := map[string]bool{}
+
+	for _, header := range []http.Header{
+		{},
+		{"Authorization": []string{"Bearer alice"}},
+		{"Authorization": []string{"Bearer bob"}},
+		{"Cookie": []string{"session=alice"}},
+		{"Cookie": []string{"session=bob"}},
+	} {
+		req, err := http.NewRequest(http.MethodGet, "https://dummy.com/block", nil)
+		c.NoError(err)
+		req.Header = header
+
+		keys[co.key(req)] = true
+	}
+
+	c.Len(keys, 5)
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	defaultMaxDecompressionRatio = 100
+	// minRatioCheckSize is the decompressed size under which the ratio is not checked,
+	// small bodies can't exhaust the memory no matter how well they compress
+	minRatioCheckSize = 1 << 20
+	// zstdMaxWindowSize is the max window size of zstd content codings on RFC 9659, bigger windows are refused
+	// so a response can't make the decoder allocate more than that
+	zstdMaxWindowSize = 8 << 20
+)
+
+var (
+	// ErrDecompressionBomb when a response body decompresses over the max ratio allowed
+	ErrDecompressionBomb = errors.New("response body decompression ratio too high")
+)
+
+// Decoder returns a reader with the decompressed content of the given one
+type Decoder func(r io.Reader) (io.ReadCloser, error)
+
+// DecompressionOpts are the options of the DecompressionMiddleware
+type DecompressionOpts struct {
+	// Decoders by content coding, on top of the default br, deflate, gzip and zstd ones, which they can replace
+	Decoders map[string]Decoder
+	// MaxRatio is the max ratio between the decompressed and the compressed size of a body, defaults to 100
+	// it is only checked once the decompressed body is over 1MB
+	MaxRatio int64
+}
+
+// DecompressionMiddleware asks for the content codings it has a decoder for on the Accept-Encoding header
+// and decompresses the responses, reading a body over the max ratio fails with ErrDecompressionBomb
+// requests that already have an Accept-Encoding header are left untouched, so their responses are kept raw
+func DecompressionMiddleware(opts DecompressionOpts) Middleware {
+	decoders := defaultDecoders()
+	for encoding, decoder := range opts.Decoders {
+		decoders[strings.ToLower(encoding)] = decoder
+	}
+
+	encodings := make([]string, 0, len(decoders))
+	for encoding := range decoders {
+		encodings = append(encodings, encoding)
+	}
+	sort.Strings(encodings)
+
+	acceptEncoding := strings.Join(encodings, ", ")
+	maxRatio := valueOrDefault(opts.MaxRatio, defaultMaxDecompressionRatio)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Accept-Encoding") != "" {
+				return next.RoundTrip(req)
+			}
+
+			req = req.Clone(req.Context())
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return resp, err
+			}
+
+			return decompressResponse(req, resp, decoders, maxRatio), nil
+		})
+	}
+}
+
+// defaultDecoders returns the decoders of the br, deflate, gzip and zstd content codings
+func defaultDecoders() map[string]Decoder {
+	return map[string]Decoder{
+		"br": func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(brotli.NewReader(r)), nil
+		},
+		"deflate": func(r io.Reader) (io.ReadCloser, error) {
+			return zlib.NewReader(r)
+		},
+		"gzip": func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		"zstd": func(r io.Reader) (io.ReadCloser, error) {
+			decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(zstdMaxWindowSize))
+			if err != nil {
+				return nil, err
+			}
+
+			return decoder.IOReadCloser(), nil
+		},
+	}
+}
+
+// decompressResponse replaces the response body with its decompressed content, if it has a decoder for it
+// bodies with many codings are left as they are
+func decompressResponse(req *http.Request, resp *http.Response, decoders map[string]Decoder, maxRatio int64) *http.Response {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	decoder, ok := decoders[encoding]
+	if !ok || req.Method == http.MethodHead || resp.ContentLength == 0 {
+		return resp
+	}
+
+	resp.Body = &decodedBody{
+		body:       resp.Body,
+		compressed: &countingReader{reader: resp.Body},
+		decoder:    decoder,
+		maxRatio:   maxRatio,
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+
+	return resp
+}
+
+// decodedBody decompresses the body on the first read, failing once it goes over the max ratio
+type decodedBody struct {
+	body         io.ReadCloser
+	compressed   *countingReader
+	decoder      Decoder
+	reader       io.ReadCloser
+	maxRatio     int64
+	decompressed int64
+	err          error
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.reader, b.err = b.decoder(b.compressed)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	n, err := b.reader.Read(p)
+	b.decompressed += int64(n)
+
+	if b.decompressed > minRatioCheckSize && b.decompressed > b.maxRatio*b.compressed.read {
+		b.err = fmt.Errorf("%w: %d bytes from %d", ErrDecompressionBomb, b.decompressed, b.compressed.read)
+		return n, b.err
+	}
+
+	return n, err
+}
+
+func (b *decodedBody) Close() error {
+	if b.reader != nil {
+		_ = b.reader.Close()
+	}
+
+	return b.body.Close()
+}
+
+// countingReader counts the bytes read from the reader
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,190 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/jarcoal/httpmock"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	var writer io.WriteCloser
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "deflate":
+		writer = zlib.NewWriter(&buf)
+	case "br":
+		writer = brotli.NewWriter(&buf)
+	case "zstd":
+		var err error
+		writer, err = zstd.NewWriter(&buf)
+		require.NoError(t, err)
+	default:
+		return data
+	}
+
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+func compressedResponder(t *testing.T, encoding string, data []byte, acceptEncoding *string) httpmock.Responder {
+	body := compress(t, encoding, data)
+
+	return func(req *http.Request) (*http.Response, error) {
+		*acceptEncoding = req.Header.Get("Accept-Encoding")
+
+		resp := httpmock.NewBytesResponse(http.StatusOK, body)
+		resp.Header.Set("Content-Encoding", encoding)
+		resp.ContentLength = int64(len(body))
+
+		return resp, nil
+	}
+}
+
+func TestDecompressionMiddleware(t *testing.T) {
+	c := require.New(t)
+
+	// identity decoder standing for a custom content coding
+	customDecoder := func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(r), nil
+	}
+
+	tests := []struct {
+		name                   string
+		encoding               string
+		acceptEncoding         string
+		expectedAcceptEncoding string
+		expectedBody           string
+		expectedEncoding       string
+	}{
+		{name: "gzip", encoding: "gzip", expectedAcceptEncoding: "br, deflate, gzip, x-custom, zstd", expectedBody: `{"ohana": "family"}`},
+		{name: "deflate", encoding: "deflate", expectedAcceptEncoding: "br, deflate, gzip, x-custom, zstd", expectedBody: `{"ohana": "family"}`},
+		{name: "br", encoding: "br", expectedAcceptEncoding: "br, deflate, gzip, x-custom, zstd", expectedBody: `{"ohana": "family"}`},
+		{name: "zstd", encoding: "zstd", expectedAcceptEncoding: "br, deflate, gzip, x-custom, zstd", expectedBody: `{"ohana": "family"}`},
+		{name: "registered decoder", encoding: "x-custom", expectedAcceptEncoding: "br, deflate, gzip, x-custom, zstd", expectedBody: `{"ohana": "family"}`},
+		{name: "unknown encoding", encoding: "compress", expectedAcceptEncoding: "br, deflate, gzip, x-custom, zstd", expectedBody: `{"ohana": "family"}`, expectedEncoding: "compress"},
+		{name: "accept encoding set by the caller", encoding: "gzip", acceptEncoding: "gzip", expectedAcceptEncoding: "gzip", expectedEncoding: "gzip"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var acceptEncoding string
+
+			transport := Chain(
+				RoundTripperFunc(compressedResponder(t, test.encoding, []byte(`{"ohana": "family"}`), &acceptEncoding)),
+				DecompressionMiddleware(DecompressionOpts{Decoders: map[string]Decoder{"X-Custom": customDecoder}}),
+			)
+
+			req, err := http.NewRequest(http.MethodGet, "https://dummy.com", nil)
+			c.NoError(err)
+
+			if test.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+
+			resp, err := transport.RoundTrip(req)
+			c.NoError(err)
+			defer resp.Body.Close()
+
+			c.Equal(test.expectedAcceptEncoding, acceptEncoding)
+			c.Equal(test.expectedEncoding, resp.Header.Get("Content-Encoding"))
+
+			body, err := io.ReadAll(resp.Body)
+			c.NoError(err)
+
+			if test.expectedBody != "" {
+				c.Equal(test.expectedBody, string(body))
+				c.Equal(test.expectedEncoding == "", resp.Uncompressed)
+			}
+		})
+	}
+}
+
+func TestDecompressionMiddleware_Bomb(t *testing.T) {
+	c := require.New(t)
+
+	data := make([]byte, 4<<20)
+
+	tests := []struct {
+		name        string
+		maxRatio    int64
+		expectedErr error
+	}{
+		{name: "over the default ratio", expectedErr: ErrDecompressionBomb},
+		{name: "under the given ratio", maxRatio: 10_000},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var acceptEncoding string
+
+			transport := Chain(
+				RoundTripperFunc(compressedResponder(t, "gzip", data, &acceptEncoding)),
+				DecompressionMiddleware(DecompressionOpts{MaxRatio: test.maxRatio}),
+			)
+
+			req, err := http.NewRequest(http.MethodGet, "https://dummy.com", nil)
+			c.NoError(err)
+
+			resp, err := transport.RoundTrip(req)
+			c.NoError(err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if test.expectedErr != nil {
+				c.ErrorIs(err, test.expectedErr)
+				c.Less(len(body), len(data))
+				return
+			}
+
+			c.NoError(err)
+			c.Equal(data, body)
+		})
+	}
+}
+
+func TestClient_Decompression(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var acceptEncoding string
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/blocks",
+		compressedResponder(t, "gzip", []byte(strings.Repeat("a", 1<<10)), &acceptEncoding))
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Timeout:         time.Second,
+		MaxResponseSize: 100,
+		Decompression:   &DecompressionOpts{},
+	})
+
+	// The max response size applies to the decompressed body
+	resp, err := client.GetWithURLAndParams("https://dummy.com/blocks", nil, nil)
+	c.NoError(err)
+	defer resp.Body.Close()
+
+	c.Equal("br, deflate, gzip, zstd", acceptEncoding)
+
+	body, err := io.ReadAll(resp.Body)
+	c.ErrorIs(err, ErrBodyTooLarge)
+	c.Equal(strings.Repeat("a", 100), string(body))
+}
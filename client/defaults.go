@@ -0,0 +1,107 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// resolveURL joins relative URLs with the client base URL, absolute URLs are returned as they are
+func (c *Client) resolveURL(reqURL *url.URL) (*url.URL, error) {
+	if c.baseURL == "" || reqURL.IsAbs() || reqURL.Host != "" {
+		return reqURL, nil
+	}
+
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return rebaseURL(base, reqURL), nil
+}
+
+// withDefaults returns the request with the client default headers and query params it doesn't have
+// the request is copied before setting them, so the one given is not modified
+func (c *Client) withDefaults(req *http.Request) *http.Request {
+	missingHeaders := missingKeys(c.defaultHeaders, req.Header)
+
+	query := req.URL.Query()
+	missingParams := missingKeys(c.defaultQuery, query)
+
+	if len(missingHeaders) == 0 && len(missingParams) == 0 {
+		return req
+	}
+
+	req = req.WithContext(req.Context())
+
+	if len(missingHeaders) != 0 {
+		req.Header = req.Header.Clone()
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+
+		for _, key := range missingHeaders {
+			req.Header[key] = append([]string(nil), c.defaultHeaders[key]...)
+		}
+	}
+
+	if len(missingParams) != 0 {
+		missing := make(url.Values, len(missingParams))
+		for _, key := range missingParams {
+			missing[key] = c.defaultQuery[key]
+		}
+
+		reqURL := *req.URL
+		reqURL.RawQuery = appendQuery(reqURL.RawQuery, missing)
+		req.URL = &reqURL
+	}
+
+	return req
+}
+
+// appendQuery appends the params to the raw query, leaving the raw query as it is
+// so the order and encoding of its params, like the ones of signed URLs, are kept
+func appendQuery(rawQuery string, params url.Values) string {
+	encoded := params.Encode()
+
+	switch {
+	case encoded == "":
+		return rawQuery
+	case rawQuery == "":
+		return encoded
+	default:
+		return rawQuery + "&" + encoded
+	}
+}
+
+// missingKeys returns the keys of defaults that are not in values
+func missingKeys[T ~map[string][]string](defaults, values T) []string {
+	var missing []string
+
+	for key := range defaults {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing
+}
+
+// canonicalHeaders returns a copy of the headers with their keys in canonical form
+func canonicalHeaders(headers http.Header) http.Header {
+	canonical := make(http.Header, len(headers))
+	for key, values := range headers {
+		key = http.CanonicalHeaderKey(key)
+		canonical[key] = append(canonical[key], values...)
+	}
+
+	return canonical
+}
+
+func cloneValues(values url.Values) url.Values {
+	cloned := make(url.Values, len(values))
+	for key, value := range values {
+		cloned[key] = append([]string(nil), value...)
+	}
+
+	return cloned
+}
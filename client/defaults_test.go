@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_BaseURLAndDefaults(t *testing.T) {
+	c := require.New(t)
+
+	defaultHeaders := http.Header{"x-api-key": []string{"secret"}, "X-Dummy": []string{"default"}}
+	defaultParams := url.Values{"chain": []string{"eth"}, "page": []string{"1"}}
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		BaseURL:            "https://dummy.com/api/v1/",
+		DefaultHeaders:     defaultHeaders,
+		DefaultQueryParams: defaultParams,
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var requests []*http.Request
+
+	responder := func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req)
+		return httpmock.NewStringResponse(http.StatusOK, `{"ok": 1}`), nil
+	}
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/api/v1/users/1", responder)
+	httpmock.RegisterResponder(http.MethodGet, "https://other.com/users", responder)
+
+	response, err := client.GetWithURLAndParams("/users/1", url.Values{"page": []string{"2"}}, http.Header{"X-Dummy": []string{"custom"}})
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	response, err = client.NewRequest(http.MethodGet, "users/{id}").PathParam("id", "1").Do(context.Background())
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	// Absolute URLs are not joined with the base one
+	req, err := http.NewRequest(http.MethodGet, "https://other.com/users", nil)
+	c.NoError(err)
+
+	response, err = client.DoRequestWithRetries(req)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+	c.Empty(req.Header)
+	c.Empty(req.URL.RawQuery)
+
+	c.Len(requests, 3)
+
+	c.Equal("secret", requests[0].Header.Get("X-Api-Key"))
+	c.Equal("custom", requests[0].Header.Get("X-Dummy"))
+	c.Equal(url.Values{"chain": []string{"eth"}, "page": []string{"2"}}, requests[0].URL.Query())
+
+	c.Equal("default", requests[1].Header.Get("X-Dummy"))
+	c.Equal(url.Values{"chain": []string{"eth"}, "page": []string{"1"}}, requests[1].URL.Query())
+
+	c.Equal("https://other.com/users?chain=eth&page=1", requests[2].URL.String())
+	c.Equal("secret", requests[2].Header.Get("X-Api-Key"))
+
+	// Options are copied
+	c.Equal(http.Header{"x-api-key": []string{"secret"}, "X-Dummy": []string{"default"}}, defaultHeaders)
+	c.Equal(url.Values{"chain": []string{"eth"}, "page": []string{"1"}}, defaultParams)
+}
+
+func TestClient_DefaultsKeepRawQuery(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		DefaultQueryParams: url.Values{"key": []string{"k"}, "z": []string{"2"}},
+	})
+
+	req, err := http.NewRequest(http.MethodGet, "https://dummy.com?z=1&a=%zz&sig=a+b", nil)
+	c.NoError(err)
+
+	c.Equal("z=1&a=%zz&sig=a+b&key=k", client.withDefaults(req).URL.RawQuery)
+	c.Equal("z=1&a=%zz&sig=a+b", req.URL.RawQuery)
+}
+
+func TestClient_InvalidBaseURL(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{BaseURL: "://dummy.com"})
+
+	_, err := client.GetWithURLAndParams("/users", nil, nil)
+	c.Error(err)
+}
+
+func TestClient_BaseURLKeepsEscapedPath(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{BaseURL: "https://dummy.com/api"})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var req *http.Request
+	httpmock.RegisterResponder(http.MethodGet, `=~^https://dummy\.com/api/files/`, func(r *http.Request) (*http.Response, error) {
+		req = r
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	response, err := client.NewRequest(http.MethodGet, "/files/{name}").PathParam("name", "a/b").Do(context.Background())
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	c.Equal("/api/files/a%2Fb", req.URL.EscapedPath())
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoBaseURLs when a request spread across endpoints is done without base URLs
+	ErrNoBaseURLs = errors.New("no base urls")
+)
+
+// HedgeOpts are the options of a hedged request
+type HedgeOpts struct {
+	// BaseURLs are the endpoints the request is sent to, in order
+	BaseURLs []string
+	// Delay is how long to wait for a response before sending the request to the next endpoint
+	Delay time.Duration
+	// MaxParallel is the max amount of requests in flight at once, defaults to all the base URLs
+	MaxParallel int
+}
+
+type hedgeResult struct {
+	index  int
+	resp   *http.Response
+	err    error
+	cancel context.CancelFunc
+}
+
+// DoHedged sends the request to the first base URL and, every time the hedge delay passes without an
+// answer or an attempt fails, to the next one, up to MaxParallel requests in flight. The first response
+// that doesn't need to be retried is returned and the other requests are cancelled, if there is none the
+// last result is returned. Each request goes through DoRequestWithRetries and is sent to the base URL
+// joined with the path and query of the request URL. Requests must be idempotent since they can be
+// handled by more than one endpoint
+func (c *Client) DoHedged(req *http.Request, opts HedgeOpts) (*http.Response, error) {
+	if len(opts.BaseURLs) == 0 {
+		return nil, ErrNoBaseURLs
+	}
+
+	targets := make([]*url.URL, len(opts.BaseURLs))
+	for i, baseURL := range opts.BaseURLs {
+		base, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, err
+		}
+
+		targets[i] = rebaseURL(base, req.URL)
+	}
+
+	if err := BufferRequestBody(req); err != nil {
+		return nil, err
+	}
+
+	maxParallel := opts.MaxParallel
+	if maxParallel <= 0 || maxParallel > len(targets) {
+		maxParallel = len(targets)
+	}
+
+	h := &hedger{
+		client:      c,
+		req:         req,
+		targets:     targets,
+		delay:       opts.Delay,
+		maxParallel: maxParallel,
+		results:     make(chan hedgeResult, len(targets)),
+	}
+
+	return h.run()
+}
+
+type hedger struct {
+	client      *Client
+	req         *http.Request
+	targets     []*url.URL
+	delay       time.Duration
+	maxParallel int
+	results     chan hedgeResult
+
+	inFlight int
+	cancels  []context.CancelFunc
+	timer    *time.Timer
+}
+
+func (h *hedger) run() (*http.Response, error) {
+	var last hedgeResult
+
+	h.launch()
+
+	for h.inFlight > 0 {
+		select {
+		case result := <-h.results:
+			h.inFlight--
+
+			closeResult(last)
+
+			if result.err == nil && !h.client.retryPolicy(h.req, result.resp, nil) {
+				h.discard(result.index)
+				return winner(result)
+			}
+
+			last = result
+			h.launch()
+		case <-h.timerC():
+			h.launch()
+		}
+	}
+
+	return winner(last)
+}
+
+// launch sends the request to the next target if the parallel limit allows it
+// and restarts the hedge delay timer
+func (h *hedger) launch() {
+	if len(h.cancels) < len(h.targets) && h.inFlight < h.maxParallel {
+		index := len(h.cancels)
+
+		ctx, cancel := context.WithCancel(h.req.Context())
+		h.cancels = append(h.cancels, cancel)
+		h.inFlight++
+
+		go func() {
+			hedgeReq, err := requestForTarget(ctx, h.req, h.targets[index])
+			if err != nil {
+				h.results <- hedgeResult{index: index, err: err, cancel: cancel}
+				return
+			}
+
+			resp, err := h.client.DoRequestWithRetries(hedgeReq)
+			h.results <- hedgeResult{index: index, resp: resp, err: err, cancel: cancel}
+		}()
+	}
+
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+
+	if len(h.cancels) < len(h.targets) {
+		h.timer = time.NewTimer(h.delay)
+	}
+}
+
+// timerC returns the channel of the hedge delay timer, nil if there is nothing left to launch
+func (h *hedger) timerC() <-chan time.Time {
+	if h.timer == nil {
+		return nil
+	}
+
+	return h.timer.C
+}
+
+// discard cancels all the requests but the winner one and closes the responses still in flight once they are done
+func (h *hedger) discard(winnerIndex int) {
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+
+	for i, cancel := range h.cancels {
+		if i != winnerIndex {
+			cancel()
+		}
+	}
+
+	go func(inFlight int) {
+		for i := 0; i < inFlight; i++ {
+			closeResult(<-h.results)
+		}
+	}(h.inFlight)
+}
+
+// requestForTarget clones the request with the given context and URL and a fresh copy of its body
+func requestForTarget(ctx context.Context, req *http.Request, target *url.URL) (*http.Request, error) {
+	targetReq := req.Clone(ctx)
+	targetReq.URL = target
+	targetReq.Host = ""
+
+	if req.GetBody == nil {
+		return targetReq, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	targetReq.Body = body
+
+	return targetReq, nil
+}
+
+// rebaseURL joins the base URL with the path and query of the ref URL, keeping the escaping of both paths
+func rebaseURL(base, ref *url.URL) *url.URL {
+	target := *base
+	target.Path = joinURLPath(base.Path, ref.Path)
+	target.RawPath = joinURLPath(base.EscapedPath(), ref.EscapedPath())
+
+	switch {
+	case base.RawQuery == "":
+		target.RawQuery = ref.RawQuery
+	case ref.RawQuery != "":
+		target.RawQuery = base.RawQuery + "&" + ref.RawQuery
+	}
+
+	return &target
+}
+
+// joinURLPath joins both paths with a single slash between them
+func joinURLPath(basePath, refPath string) string {
+	if refPath == "" || refPath == "/" && basePath != "" {
+		return basePath
+	}
+
+	return strings.TrimSuffix(basePath, "/") + "/" + strings.TrimPrefix(refPath, "/")
+}
+
+// winner returns the result keeping its context alive until the response body is closed
+func winner(result hedgeResult) (*http.Response, error) {
+	if result.err != nil {
+		result.cancel()
+		return nil, result.err
+	}
+
+	result.resp.Body = &cancelOnCloseBody{ReadCloser: result.resp.Body, cancel: result.cancel}
+
+	return result.resp, nil
+}
+
+func closeResult(result hedgeResult) {
+	if result.resp != nil {
+		drainAndClose(result.resp.Body)
+	}
+
+	if result.cancel != nil {
+		result.cancel()
+	}
+}
+
+// cancelOnCloseBody cancels the context of its request once the body is closed
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pokt-foundation/utils-go/mock-client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_DoHedged(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{Timeout: 5 * time.Second})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var slowCancelled atomic.Bool
+
+	httpmock.RegisterResponder(http.MethodPost, "https://slow.com/v1/relay", func(req *http.Request) (*http.Response, error) {
+		select {
+		case <-req.Context().Done():
+			slowCancelled.Store(true)
+			return nil, req.Context().Err()
+		case <-time.After(2 * time.Second):
+			return httpmock.NewStringResponse(http.StatusOK, `{"from": "slow"}`), nil
+		}
+	})
+	httpmock.RegisterResponder(http.MethodPost, "https://fast.com/relay", func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		c.NoError(err)
+		c.Equal(`{"method":"eth_blockNumber"}`, string(body))
+		c.Equal("ohana", req.URL.Query().Get("family"))
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"from": "fast"}`), nil
+	})
+
+	req, err := http.NewRequest(http.MethodPost, "/relay?family=ohana", io.MultiReader(
+		strings.NewReader(`{"method":"eth_blockNumber"}`),
+	))
+	c.NoError(err)
+
+	start := time.Now()
+
+	response, err := client.DoHedged(req, HedgeOpts{
+		BaseURLs: []string{"https://slow.com/v1/", "https://fast.com"},
+		Delay:    20 * time.Millisecond,
+	})
+	c.NoError(err)
+	c.Less(time.Since(start), time.Second)
+
+	body, err := io.ReadAll(response.Body)
+	c.NoError(err)
+	c.Equal(`{"from": "fast"}`, string(body))
+	c.NoError(response.Body.Close())
+
+	c.Eventually(slowCancelled.Load, time.Second, 10*time.Millisecond)
+}
+
+func TestClient_DoHedgedFailover(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodGet, "https://first.com/status", http.StatusBadGateway, `{"from": "first"}`)
+	mock.AddMockedResponse(http.MethodGet, "https://second.com/status", http.StatusServiceUnavailable, `{"from": "second"}`)
+	mock.AddMockedResponse(http.MethodGet, "https://third.com/status", http.StatusOK, `{"from": "third"}`)
+
+	req, err := http.NewRequest(http.MethodGet, "/status", nil)
+	c.NoError(err)
+
+	start := time.Now()
+
+	// Failed attempts launch the next endpoint without waiting the hedge delay
+	response, err := client.DoHedged(req, HedgeOpts{
+		BaseURLs:    []string{"https://first.com", "https://second.com", "https://third.com"},
+		Delay:       time.Minute,
+		MaxParallel: 1,
+	})
+	c.NoError(err)
+	c.Equal(http.StatusOK, response.StatusCode)
+	c.NoError(response.Body.Close())
+	c.Less(time.Since(start), time.Second)
+
+	// Without a successful response the last one is returned
+	response, err = client.DoHedged(req, HedgeOpts{
+		BaseURLs: []string{"https://first.com", "https://second.com"},
+		Delay:    time.Millisecond,
+	})
+	c.NoError(err)
+	c.Contains([]int{http.StatusBadGateway, http.StatusServiceUnavailable}, response.StatusCode)
+	c.NoError(response.Body.Close())
+
+	_, err = client.DoHedged(req, HedgeOpts{
+		BaseURLs: []string{"https://missing.com"},
+	})
+	c.Error(err)
+
+	_, err = client.DoHedged(req, HedgeOpts{})
+	c.ErrorIs(err, ErrNoBaseURLs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = client.DoHedged(req.WithContext(ctx), HedgeOpts{BaseURLs: []string{"https://third.com"}})
+	c.ErrorIs(err, context.Canceled)
+}
+
+func TestRebaseURL(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		base, ref, expected string
+	}{
+		{base: "https://dummy.com", ref: "/relay", expected: "https://dummy.com/relay"},
+		{base: "https://dummy.com/", ref: "relay", expected: "https://dummy.com/relay"},
+		{base: "https://dummy.com/v1/", ref: "/relay", expected: "https://dummy.com/v1/relay"},
+		{base: "https://dummy.com/v1", ref: "", expected: "https://dummy.com/v1"},
+		{base: "https://dummy.com/v1", ref: "/", expected: "https://dummy.com/v1"},
+		{base: "https://dummy.com/v1?key=1", ref: "/relay?family=ohana", expected: "https://dummy.com/v1/relay?key=1&family=ohana"},
+		{base: "https://dummy.com/api", ref: "/files/a%2Fb", expected: "https://dummy.com/api/files/a%2Fb"},
+		{base: "https://dummy.com/a%2Fb/", ref: "/files", expected: "https://dummy.com/a%2Fb/files"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.base+" "+test.ref, func(t *testing.T) {
+			base, err := url.Parse(test.base)
+			c.NoError(err)
+			ref, err := url.Parse(test.ref)
+			c.NoError(err)
+
+			c.Equal(test.expected, rebaseURL(base, ref).String())
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const (
+	defaultMaxResponseSize = 10 << 20
+	maxErrorBodySize       = 1 << 10
+)
+
+var (
+	// ErrBodyTooLarge when a response body is bigger than the max size allowed
+	ErrBodyTooLarge = errors.New("response body too large")
+)
+
+// HTTPError is returned by the JSON helpers when the response status code is not 2xx
+type HTTPError struct {
+	StatusCode int
+	Header     http.Header
+	// Body is the start of the response body, truncated to 1KB
+	Body []byte
+}
+
+// Error returns the error message with the status code and the truncated body
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Body)
+}
+
+// GetJSON does get request with url values as params and decodes the JSON response into T
+func GetJSON[T any](ctx context.Context, c *Client, rawURL string, params url.Values, headers http.Header) (T, error) {
+	resp, err := c.GetWithURLAndParamsWithCtx(ctx, rawURL, params, jsonHeaders(headers))
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return DecodeJSONResponse[T](resp, c.maxResponseSize)
+}
+
+// PostJSON does post request with JSON param and decodes the JSON response into T
+func PostJSON[T any](ctx context.Context, c *Client, rawURL string, params any, headers http.Header) (T, error) {
+	resp, err := c.PostWithURLJSONParamsWithCtx(ctx, rawURL, params, jsonHeaders(headers))
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return DecodeJSONResponse[T](resp, c.maxResponseSize)
+}
+
+// PutJSON does put request with JSON param and decodes the JSON response into T
+func PutJSON[T any](ctx context.Context, c *Client, rawURL string, params any, headers http.Header) (T, error) {
+	resp, err := c.PutWithURLJSONParamsWithCtx(ctx, rawURL, params, jsonHeaders(headers))
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return DecodeJSONResponse[T](resp, c.maxResponseSize)
+}
+
+// DecodeJSONResponse reads and closes the response body decoding it into T
+// non 2xx responses return an *HTTPError and bodies bigger than maxSize return ErrBodyTooLarge
+// an empty body on a 2xx response returns the zero value of T
+func DecodeJSONResponse[T any](resp *http.Response, maxSize int64) (T, error) {
+	defer resp.Body.Close()
+
+	var result T
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return result, newHTTPError(resp)
+	}
+
+	data, err := readBody(resp.Body, maxSize)
+	if err != nil {
+		return result, err
+	}
+
+	if len(data) == 0 {
+		return result, nil
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// newHTTPError builds the error for a non 2xx response reading the start of its body
+func newHTTPError(resp *http.Response) *HTTPError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+}
+
+// readBody reads the whole body returning a *BodyTooLargeError if it's bigger than maxSize
+// a maxSize lower than one uses the default max size
+func readBody(body io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize < 1 {
+		maxSize = defaultMaxResponseSize
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, &BodyTooLargeError{Limit: maxSize}
+	}
+
+	return data, nil
+}
+
+// jsonHeaders returns a copy of the given headers accepting JSON responses
+func jsonHeaders(headers http.Header) http.Header {
+	headers = headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+
+	if headers.Get("Accept") == "" {
+		headers.Set("Accept", "application/json")
+	}
+
+	return headers
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pokt-foundation/utils-go/mock-client"
+	"github.com/stretchr/testify/require"
+)
+
+type dummyResponse struct {
+	Ohana string `json:"ohana"`
+}
+
+func TestGetJSON(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponseFromFile(http.MethodGet, "https://dummy.com", http.StatusOK, "../mock-client/samples/dummy.json")
+	mock.AddMockedResponse(http.MethodGet, "https://dummy.com/missing", http.StatusNotFound, `{"error": "not found"}`)
+
+	response, err := GetJSON[dummyResponse](context.Background(), client, "https://dummy.com", url.Values{}, nil)
+	c.NoError(err)
+	c.Equal(dummyResponse{Ohana: "means family"}, response)
+
+	_, err = GetJSON[dummyResponse](context.Background(), client, "https://dummy.com/missing", url.Values{}, nil)
+
+	var httpErr *HTTPError
+	c.ErrorAs(err, &httpErr)
+	c.Equal(http.StatusNotFound, httpErr.StatusCode)
+	c.Equal(`{"error": "not found"}`, string(httpErr.Body))
+	c.Equal(`unexpected status code 404: {"error": "not found"}`, httpErr.Error())
+}
+
+func TestPostJSON(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodPost, "https://dummy.com", func(req *http.Request) (*http.Response, error) {
+		c.Equal("application/json", req.Header.Get("Accept"))
+		c.Equal("application/json", req.Header.Get("Content-Type"))
+
+		if req.Body == nil {
+			return httpmock.NewStringResponse(http.StatusNoContent, ""), nil
+		}
+
+		body, err := io.ReadAll(req.Body)
+		c.NoError(err)
+
+		return httpmock.NewStringResponse(http.StatusCreated, string(body)), nil
+	})
+
+	headers := http.Header{}
+
+	response, err := PostJSON[dummyResponse](context.Background(), client, "https://dummy.com", dummyResponse{Ohana: "family"}, headers)
+	c.NoError(err)
+	c.Equal(dummyResponse{Ohana: "family"}, response)
+	c.Empty(headers)
+
+	response, err = PostJSON[dummyResponse](context.Background(), client, "https://dummy.com", nil, nil)
+	c.NoError(err)
+	c.Equal(dummyResponse{}, response)
+}
+
+func TestPutJSON(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodPut, "https://dummy.com", http.StatusOK, `{"ohana": "family"}`)
+	mock.AddMockedResponse(http.MethodPut, "https://dummy.com/invalid", http.StatusOK, `{"ohana": `)
+
+	response, err := PutJSON[dummyResponse](context.Background(), client, "https://dummy.com", dummyResponse{Ohana: "family"}, nil)
+	c.NoError(err)
+	c.Equal(dummyResponse{Ohana: "family"}, response)
+
+	_, err = PutJSON[dummyResponse](context.Background(), client, "https://dummy.com/invalid", dummyResponse{Ohana: "family"}, nil)
+	c.Error(err)
+}
+
+func TestDecodeJSONResponse(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name            string
+		statusCode      int
+		body            string
+		maxSize         int64
+		expected        dummyResponse
+		expectedErr     error
+		expectHTTPError bool
+	}{
+		{name: "ok", statusCode: http.StatusOK, body: `{"ohana": "family"}`, expected: dummyResponse{Ohana: "family"}},
+		{name: "empty body", statusCode: http.StatusNoContent, body: "", expected: dummyResponse{}},
+		{name: "too large", statusCode: http.StatusOK, body: `{"ohana": "family"}`, maxSize: 5, expectedErr: ErrBodyTooLarge},
+		{name: "server error", statusCode: http.StatusBadGateway, body: strings.Repeat("a", 2*maxErrorBodySize), expectHTTPError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.statusCode,
+				Header:     http.Header{"X-Dummy": []string{"ohana"}},
+				Body:       io.NopCloser(strings.NewReader(test.body)),
+			}
+
+			response, err := DecodeJSONResponse[dummyResponse](resp, test.maxSize)
+			if test.expectedErr != nil {
+				c.ErrorIs(err, test.expectedErr)
+				return
+			}
+
+			if test.expectHTTPError {
+				var httpErr *HTTPError
+				c.ErrorAs(err, &httpErr)
+				c.Equal(test.statusCode, httpErr.StatusCode)
+				c.Equal("ohana", httpErr.Header.Get("X-Dummy"))
+				c.Len(httpErr.Body, maxErrorBodySize)
+				return
+			}
+
+			c.NoError(err)
+			c.Equal(test.expected, response)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const jsonRPCVersion = "2.0"
+
+var (
+	// ErrRPCMissingResponse when a batch response has no element for one of the calls
+	ErrRPCMissingResponse = errors.New("missing json-rpc response")
+	// ErrRPCEmptyBatch when a batch call is done without calls
+	ErrRPCEmptyBatch = errors.New("empty json-rpc batch")
+)
+
+// RPCError is the error object returned on a JSON-RPC response
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Error returns the error message with its code
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", e.Code, e.Message)
+}
+
+// BatchElem is a single call of a batch request, Result and Error are set once the batch is done
+type BatchElem struct {
+	Method string
+	Params any
+	// Result is a pointer where the call result is decoded, it can be nil to ignore it
+	Result any
+	Error  error
+}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+}
+
+// key returns the response ID as the string used to match it with its request
+func (r *rpcResponse) key() string {
+	return strings.Trim(string(r.ID), `"`)
+}
+
+// decode sets the response result into the given pointer or returns its error
+func (r *rpcResponse) decode(result any) error {
+	if r.Error != nil {
+		return r.Error
+	}
+
+	if result == nil || len(r.Result) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(r.Result, result)
+}
+
+// RPCClient does JSON-RPC 2.0 calls to a single endpoint using the retries and options of a Client
+type RPCClient struct {
+	client  *Client
+	url     string
+	headers http.Header
+	nextID  atomic.Uint64
+}
+
+// NewRPCClient returns a JSON-RPC client that posts to the given url with the given headers
+func NewRPCClient(client *Client, url string, headers http.Header) *RPCClient {
+	return &RPCClient{
+		client:  client,
+		url:     url,
+		headers: headers.Clone(),
+	}
+}
+
+// Call does a JSON-RPC call decoding its result into the result pointer
+// errors returned by the node are returned as *RPCError
+func (r *RPCClient) Call(ctx context.Context, method string, params any, result any) error {
+	req := r.newRequest(method, params)
+
+	var resp rpcResponse
+	if err := r.post(ctx, req, &resp); err != nil {
+		return err
+	}
+
+	if resp.Error == nil && resp.key() != strconv.FormatUint(req.ID, 10) {
+		return fmt.Errorf("%w: unexpected id %s", ErrRPCMissingResponse, resp.ID)
+	}
+
+	return resp.decode(result)
+}
+
+// BatchCall does all the calls in a single JSON-RPC batch request
+// responses are matched to their calls by ID, so the order returned by the node doesn't matter
+// the returned error is set if the whole batch failed, errors of single calls are set on each element
+func (r *RPCClient) BatchCall(ctx context.Context, batch []BatchElem) error {
+	if len(batch) == 0 {
+		return ErrRPCEmptyBatch
+	}
+
+	reqs := make([]rpcRequest, len(batch))
+	for i, elem := range batch {
+		reqs[i] = r.newRequest(elem.Method, elem.Params)
+	}
+
+	var resps []rpcResponse
+	if err := r.post(ctx, reqs, &resps); err != nil {
+		return err
+	}
+
+	byID := make(map[string]*rpcResponse, len(resps))
+	for i := range resps {
+		byID[resps[i].key()] = &resps[i]
+	}
+
+	for i, req := range reqs {
+		resp, ok := byID[strconv.FormatUint(req.ID, 10)]
+		if !ok {
+			batch[i].Error = ErrRPCMissingResponse
+			continue
+		}
+
+		batch[i].Error = resp.decode(batch[i].Result)
+	}
+
+	return nil
+}
+
+func (r *RPCClient) newRequest(method string, params any) rpcRequest {
+	return rpcRequest{
+		JSONRPC: jsonRPCVersion,
+		ID:      r.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	}
+}
+
+// post sends the payload and decodes the response body into result
+// a body with a JSON-RPC error object is returned as *RPCError even on non 2xx responses
+func (r *RPCClient) post(ctx context.Context, payload any, result any) error {
+	resp, err := r.client.PostWithURLJSONParamsWithCtx(ctx, r.url, payload, jsonHeaders(r.headers))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := readBody(resp.Body, r.client.maxResponseSize)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return rpcErrorFromBody(resp, data)
+	}
+
+	if err := json.Unmarshal(data, result); err != nil {
+		// A batch that is invalid as a whole is answered with a single error object
+		var single rpcResponse
+		if json.Unmarshal(data, &single) == nil && single.Error != nil {
+			return single.Error
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// rpcErrorFromBody returns the JSON-RPC error on the body or an *HTTPError if there is none
+func rpcErrorFromBody(resp *http.Response, data []byte) error {
+	var single rpcResponse
+	if json.Unmarshal(data, &single) == nil && single.Error != nil {
+		return single.Error
+	}
+
+	if len(data) > maxErrorBodySize {
+		data = data[:maxErrorBodySize]
+	}
+
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       data,
+	}
+}
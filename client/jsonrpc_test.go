@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pokt-foundation/utils-go/mock-client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPCClient_Call(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodPost, "https://node.com", func(req *http.Request) (*http.Response, error) {
+		var rpcReq rpcRequest
+		c.NoError(json.NewDecoder(req.Body).Decode(&rpcReq))
+		c.Equal("2.0", rpcReq.JSONRPC)
+		c.Equal("application/json", req.Header.Get("Content-Type"))
+		c.Equal("ohana", req.Header.Get("X-Dummy"))
+
+		if rpcReq.Method == "eth_fail" {
+			return httpmock.NewStringResponse(http.StatusOK, fmt.Sprintf(
+				`{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"execution reverted","data":"0x01"}}`, rpcReq.ID)), nil
+		}
+
+		return httpmock.NewStringResponse(http.StatusOK, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"0x10"}`, rpcReq.ID)), nil
+	})
+
+	rpc := NewRPCClient(NewDefaultClient(), "https://node.com", http.Header{"X-Dummy": []string{"ohana"}})
+
+	var blockNumber string
+	c.NoError(rpc.Call(context.Background(), "eth_blockNumber", nil, &blockNumber))
+	c.Equal("0x10", blockNumber)
+
+	c.NoError(rpc.Call(context.Background(), "eth_blockNumber", []any{}, nil))
+
+	err := rpc.Call(context.Background(), "eth_fail", []any{"0x1"}, &blockNumber)
+
+	var rpcErr *RPCError
+	c.ErrorAs(err, &rpcErr)
+	c.Equal(-32000, rpcErr.Code)
+	c.Equal("execution reverted", rpcErr.Message)
+	c.JSONEq(`"0x01"`, string(rpcErr.Data))
+	c.Equal("json-rpc error -32000: execution reverted", rpcErr.Error())
+}
+
+func TestRPCClient_CallHTTPError(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodPost, "https://node.com", http.StatusBadGateway, "bad gateway")
+	mock.AddMockedResponse(http.MethodPost, "https://node.com/rpc", http.StatusInternalServerError,
+		`{"jsonrpc":"2.0","id":null,"error":{"code":-32603,"message":"internal error"}}`)
+
+	rpc := NewRPCClient(NewDefaultClient(), "https://node.com", nil)
+
+	var httpErr *HTTPError
+	c.ErrorAs(rpc.Call(context.Background(), "eth_blockNumber", nil, nil), &httpErr)
+	c.Equal(http.StatusBadGateway, httpErr.StatusCode)
+
+	rpc = NewRPCClient(NewDefaultClient(), "https://node.com/rpc", nil)
+
+	var rpcErr *RPCError
+	c.ErrorAs(rpc.Call(context.Background(), "eth_blockNumber", nil, nil), &rpcErr)
+	c.Equal(-32603, rpcErr.Code)
+}
+
+func TestRPCClient_BatchCall(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodPost, "https://node.com", func(req *http.Request) (*http.Response, error) {
+		var rpcReqs []rpcRequest
+		c.NoError(json.NewDecoder(req.Body).Decode(&rpcReqs))
+		c.Len(rpcReqs, 3)
+
+		// Responses are sent in reverse order and the last call has no response
+		return httpmock.NewStringResponse(http.StatusOK, fmt.Sprintf(`[
+			{"jsonrpc":"2.0","id":"%d","error":{"code":-32601,"message":"method not found"}},
+			{"jsonrpc":"2.0","id":%d,"result":"0x1"}
+		]`, rpcReqs[1].ID, rpcReqs[0].ID)), nil
+	})
+
+	rpc := NewRPCClient(NewDefaultClient(), "https://node.com", nil)
+
+	var chainID string
+	batch := []BatchElem{
+		{Method: "eth_chainId", Result: &chainID},
+		{Method: "eth_dummy", Params: []any{1}},
+		{Method: "eth_blockNumber"},
+	}
+
+	c.NoError(rpc.BatchCall(context.Background(), batch))
+	c.NoError(batch[0].Error)
+	c.Equal("0x1", chainID)
+
+	var rpcErr *RPCError
+	c.ErrorAs(batch[1].Error, &rpcErr)
+	c.Equal(-32601, rpcErr.Code)
+
+	c.ErrorIs(batch[2].Error, ErrRPCMissingResponse)
+
+	c.ErrorIs(rpc.BatchCall(context.Background(), nil), ErrRPCEmptyBatch)
+}
+
+func TestRPCClient_BatchCallInvalidBatch(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodPost, "https://node.com", http.StatusOK,
+		`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"invalid request"}}`)
+
+	rpc := NewRPCClient(NewDefaultClient(), "https://node.com", nil)
+
+	var rpcErr *RPCError
+	c.ErrorAs(rpc.BatchCall(context.Background(), []BatchElem{{Method: "eth_chainId"}}), &rpcErr)
+	c.Equal(-32600, rpcErr.Code)
+}
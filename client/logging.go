@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pokt-foundation/utils-go/logger"
+)
+
+const redactedValue = "REDACTED"
+
+var (
+	defaultRedactHeaders     = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	defaultRedactQueryParams = []string{"api_key", "apikey", "key", "token", "access_token"}
+)
+
+type attemptKey struct{}
+
+// RequestLogOpts are the options of the request logging
+type RequestLogOpts struct {
+	// Logger defaults to logger.New, so its level and format are set with the LOG_LEVEL and LOG_HANDLER env vars
+	Logger *logger.Logger
+	// LogHeaders adds the request headers to the logs
+	LogHeaders bool
+	// RedactHeaders are the headers logged as REDACTED
+	// defaults to Authorization, Proxy-Authorization, Cookie, Set-Cookie and X-Api-Key
+	RedactHeaders []string
+	// RedactQueryParams are the query params logged as REDACTED
+	// defaults to api_key, apikey, key, token and access_token
+	RedactQueryParams []string
+}
+
+// withDefaults returns a copy of the options with the default logger and redactions where not set
+func (o RequestLogOpts) withDefaults() RequestLogOpts {
+	if o.Logger == nil {
+		o.Logger = logger.New()
+	}
+
+	if o.RedactHeaders == nil {
+		o.RedactHeaders = defaultRedactHeaders
+	}
+
+	if o.RedactQueryParams == nil {
+		o.RedactQueryParams = defaultRedactQueryParams
+	}
+
+	return o
+}
+
+// LoggingMiddleware logs every request sent with its method, redacted URL, status, attempt number,
+// duration until the response headers and bytes. Successful requests are logged at debug level, 4xx responses
+// at warn level and 5xx responses and transport errors at error level. The bytes are the ones actually sent
+// and read, so responses are logged once their body is closed
+func LoggingMiddleware(opts RequestLogOpts) Middleware {
+	opts = opts.withDefaults()
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+
+			req, requestBody := withCountedBody(req)
+
+			resp, err := next.RoundTrip(req)
+			duration := time.Since(start)
+
+			if err != nil {
+				opts.logRequest(req, duration, requestBody.count(), nil, 0, err)
+				return resp, err
+			}
+
+			// The body of an upgrade response is the connection, which must stay writable
+			if resp.StatusCode == http.StatusSwitchingProtocols {
+				opts.logRequest(req, duration, requestBody.count(), resp, 0, nil)
+				return resp, nil
+			}
+
+			resp.Body = &countedBody{
+				ReadCloser: resp.Body,
+				onClose: func(responseBytes int64) {
+					opts.logRequest(req, duration, requestBody.count(), resp, responseBytes, nil)
+				},
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+// logRequest logs the request with the level of its result
+func (o RequestLogOpts) logRequest(req *http.Request, duration time.Duration, requestBytes int64,
+	resp *http.Response, responseBytes int64, err error) {
+	args := []any{
+		"method", req.Method,
+		"url", redactURL(req.URL, o.RedactQueryParams),
+		"attempt", AttemptFromContext(req.Context()),
+		"duration", duration,
+		"request_bytes", requestBytes,
+	}
+
+	if o.LogHeaders {
+		args = append(args, "headers", redactHeaders(req.Header, o.RedactHeaders))
+	}
+
+	if err != nil {
+		o.Logger.Error("request failed", append(args, "error", err.Error())...)
+		return
+	}
+
+	args = append(args, "status", resp.StatusCode, "response_bytes", responseBytes)
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		o.Logger.Error("request failed", args...)
+	case resp.StatusCode >= http.StatusBadRequest:
+		o.Logger.Warn("request failed", args...)
+	default:
+		o.Logger.Debug("request done", args...)
+	}
+}
+
+// withCountedBody returns a copy of the request counting the bytes read from its body
+func withCountedBody(req *http.Request) (*http.Request, *countedBody) {
+	body := &countedBody{}
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, body
+	}
+
+	body.ReadCloser = req.Body
+
+	counted := req.Clone(req.Context())
+	counted.Body = body
+
+	return counted, body
+}
+
+// countedBody counts the bytes read from the body, calling onClose with them once it's closed
+// the count is safe to get while the transport reads the body on another goroutine
+type countedBody struct {
+	io.ReadCloser
+	read    atomic.Int64
+	closed  atomic.Bool
+	onClose func(read int64)
+}
+
+func (b *countedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read.Add(int64(n))
+
+	return n, err
+}
+
+func (b *countedBody) Close() error {
+	err := b.ReadCloser.Close()
+
+	if b.onClose != nil && b.closed.CompareAndSwap(false, true) {
+		b.onClose(b.read.Load())
+	}
+
+	return err
+}
+
+func (b *countedBody) count() int64 {
+	return b.read.Load()
+}
+
+// logRetry logs at warn level that the request is going to be retried
+func (c *Client) logRetry(req *http.Request, attempt int, delay time.Duration, resp *http.Response, err error) {
+	if c.requestLog == nil {
+		return
+	}
+
+	args := []any{
+		"method", req.Method,
+		"url", redactURL(req.URL, c.requestLog.RedactQueryParams),
+		"attempt", attempt,
+		"delay", delay,
+	}
+
+	if err != nil {
+		args = append(args, "error", err.Error())
+	} else {
+		args = append(args, "status", resp.StatusCode)
+	}
+
+	c.requestLog.Logger.Warn("retrying request", args...)
+}
+
+// AttemptFromContext returns the attempt number of the request being sent, starting at 1
+// it is zero for requests not sent with DoRequestWithRetries
+func AttemptFromContext(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
+
+// redactURL returns the URL without password and with the given query params redacted
+func redactURL(u *url.URL, params []string) string {
+	query := u.Query()
+	redacted := false
+
+	for key := range query {
+		if containsFold(params, key) {
+			query.Set(key, redactedValue)
+			redacted = true
+		}
+	}
+
+	if !redacted {
+		return u.Redacted()
+	}
+
+	cloned := *u
+	cloned.RawQuery = query.Encode()
+
+	return cloned.Redacted()
+}
+
+// redactHeaders returns a copy of the headers with the given ones redacted
+func redactHeaders(headers http.Header, redact []string) http.Header {
+	redacted := headers.Clone()
+
+	for key := range redacted {
+		if containsFold(redact, key) {
+			redacted[key] = []string{redactedValue}
+		}
+	}
+
+	return redacted
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	statusClassError          = "error"
+	prometheusTextContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the latency histogram buckets
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// MetricsRecorder receives the metrics of every request sent with DoRequestWithRetries
+type MetricsRecorder interface {
+	ObserveRequest(metrics RequestMetrics)
+}
+
+// RequestMetrics are the metrics of a request, including all its attempts
+type RequestMetrics struct {
+	Host   string
+	Method string
+	// StatusCode is the status of the last attempt, zero if it failed without a response
+	StatusCode int
+	// Attempts is zero for responses served from the cache without asking the server
+	Attempts int
+	// Duration goes from the first attempt until the response headers of the last one were received,
+	// including the waits between attempts
+	Duration time.Duration
+	Err      error
+}
+
+// StatusClass returns the class of the status code, like 2xx, or error if there was no response
+func (m RequestMetrics) StatusClass() string {
+	if m.StatusCode < 100 || m.StatusCode > 999 {
+		return statusClassError
+	}
+
+	return strconv.Itoa(m.StatusCode/100) + "xx"
+}
+
+func newRequestMetrics(req *http.Request, resp *http.Response, attempts int, duration time.Duration, err error) RequestMetrics {
+	metrics := RequestMetrics{
+		Host:     req.URL.Host,
+		Method:   req.Method,
+		Attempts: attempts,
+		Duration: duration,
+		Err:      err,
+	}
+
+	if resp != nil {
+		metrics.StatusCode = resp.StatusCode
+	}
+
+	return metrics
+}
+
+type metricLabels struct {
+	host        string
+	method      string
+	statusClass string
+}
+
+func (l metricLabels) String() string {
+	return fmt.Sprintf(`host="%s",method="%s",status_class="%s"`,
+		escapeLabelValue(l.host), escapeLabelValue(l.method), escapeLabelValue(l.statusClass))
+}
+
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// PrometheusMetrics is a MetricsRecorder that keeps counters and histograms in memory
+// and exposes them in the Prometheus text format, with WriteTo or as an http.Handler
+// every metric is labeled by host, method and status class
+type PrometheusMetrics struct {
+	mu        sync.Mutex
+	namespace string
+	buckets   []float64
+	requests  map[metricLabels]uint64
+	attempts  map[metricLabels]uint64
+	latencies map[metricLabels]*histogram
+}
+
+// NewPrometheusMetrics returns the metrics with the given namespace prefixed to their names
+// and latency buckets in seconds, nil buckets default to DefaultLatencyBuckets
+func NewPrometheusMetrics(namespace string, buckets []float64) *PrometheusMetrics {
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &PrometheusMetrics{
+		namespace: namespace,
+		buckets:   sorted,
+		requests:  make(map[metricLabels]uint64),
+		attempts:  make(map[metricLabels]uint64),
+		latencies: make(map[metricLabels]*histogram),
+	}
+}
+
+// ObserveRequest records the request metrics
+func (p *PrometheusMetrics) ObserveRequest(metrics RequestMetrics) {
+	labels := metricLabels{host: metrics.Host, method: metrics.Method, statusClass: metrics.StatusClass()}
+	seconds := metrics.Duration.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests[labels]++
+	p.attempts[labels] += uint64(metrics.Attempts)
+
+	latency, ok := p.latencies[labels]
+	if !ok {
+		latency = &histogram{buckets: make([]uint64, len(p.buckets))}
+		p.latencies[labels] = latency
+	}
+
+	for i, bound := range p.buckets {
+		if seconds <= bound {
+			latency.buckets[i]++
+		}
+	}
+
+	latency.count++
+	latency.sum += seconds
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	p.mu.Lock()
+	p.writeCounter(&buf, "requests_total", "Requests sent by the HTTP client.", p.requests)
+	p.writeCounter(&buf, "request_attempts_total", "Attempts made by the HTTP client, including retries.", p.attempts)
+	p.writeLatencies(&buf)
+	p.mu.Unlock()
+
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", prometheusTextContentType)
+	_, _ = p.WriteTo(w)
+}
+
+func (p *PrometheusMetrics) writeCounter(buf *bytes.Buffer, name, help string, values map[metricLabels]uint64) {
+	name = p.name(name)
+	writeMetricHeader(buf, name, help, "counter")
+
+	for _, labels := range sortedLabels(values) {
+		fmt.Fprintf(buf, "%s{%s} %d\n", name, labels, values[labels])
+	}
+}
+
+func (p *PrometheusMetrics) writeLatencies(buf *bytes.Buffer) {
+	name := p.name("request_duration_seconds")
+	writeMetricHeader(buf, name, "Duration of the requests sent by the HTTP client, including retries.", "histogram")
+
+	for _, labels := range sortedLabels(p.latencies) {
+		latency := p.latencies[labels]
+
+		for i, bound := range p.buckets {
+			fmt.Fprintf(buf, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(bound), latency.buckets[i])
+		}
+
+		fmt.Fprintf(buf, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, latency.count)
+		fmt.Fprintf(buf, "%s_sum{%s} %s\n", name, labels, formatFloat(latency.sum))
+		fmt.Fprintf(buf, "%s_count{%s} %d\n", name, labels, latency.count)
+	}
+}
+
+func (p *PrometheusMetrics) name(name string) string {
+	name = "http_client_" + name
+	if p.namespace == "" {
+		return name
+	}
+
+	return p.namespace + "_" + name
+}
+
+func writeMetricHeader(buf *bytes.Buffer, name, help, metricType string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, metricType)
+}
+
+// sortedLabels returns the labels of the metric sorted, so the output is always the same
+func sortedLabels[T any](values map[metricLabels]T) []metricLabels {
+	labels := make([]metricLabels, 0, len(values))
+	for label := range values {
+		labels = append(labels, label)
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].String() < labels[j].String()
+	})
+
+	return labels
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
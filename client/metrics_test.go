@@ -0,0 +1,106 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Metrics(t *testing.T) {
+	c := require.New(t)
+
+	metrics := NewPrometheusMetrics("pocket", []float64{10, 0.5})
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries: 2,
+		Backoff: ConstantBackoff(0),
+		Metrics: metrics,
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/block", httpmock.ResponderFromMultipleResponses([]*http.Response{
+		httpmock.NewStringResponse(http.StatusServiceUnavailable, `{"error": "unavailable"}`),
+		httpmock.NewStringResponse(http.StatusOK, `{"block": 10}`),
+	}))
+	httpmock.RegisterResponder(http.MethodPut, "https://dummy.com/relay", httpmock.NewErrorResponder(io.ErrUnexpectedEOF))
+
+	response, err := client.GetWithURLAndParams("https://dummy.com/block", nil, nil)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	_, err = client.PutWithURLJSONParams("https://dummy.com/relay", nil, nil)
+	c.Error(err)
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	c.Equal(prometheusTextContentType, recorder.Header().Get("Content-Type"))
+
+	output := recorder.Body.String()
+
+	for _, line := range []string{
+		"# TYPE pocket_http_client_requests_total counter",
+		`pocket_http_client_requests_total{host="dummy.com",method="GET",status_class="2xx"} 1`,
+		`pocket_http_client_requests_total{host="dummy.com",method="PUT",status_class="error"} 1`,
+		`pocket_http_client_request_attempts_total{host="dummy.com",method="GET",status_class="2xx"} 2`,
+		`pocket_http_client_request_attempts_total{host="dummy.com",method="PUT",status_class="error"} 3`,
+		"# TYPE pocket_http_client_request_duration_seconds histogram",
+		`pocket_http_client_request_duration_seconds_bucket{host="dummy.com",method="GET",status_class="2xx",le="0.5"} 1`,
+		`pocket_http_client_request_duration_seconds_bucket{host="dummy.com",method="GET",status_class="2xx",le="10"} 1`,
+		`pocket_http_client_request_duration_seconds_bucket{host="dummy.com",method="GET",status_class="2xx",le="+Inf"} 1`,
+		`pocket_http_client_request_duration_seconds_count{host="dummy.com",method="PUT",status_class="error"} 1`,
+	} {
+		c.Contains(output, line+"\n")
+	}
+
+	// Buckets are written sorted
+	c.Less(strings.Index(output, `le="0.5"`), strings.Index(output, `le="10"`))
+}
+
+func TestPrometheusMetrics_Histogram(t *testing.T) {
+	c := require.New(t)
+
+	metrics := NewPrometheusMetrics("", []float64{0.1, 1})
+
+	for _, duration := range []time.Duration{50 * time.Millisecond, 500 * time.Millisecond, 2 * time.Second} {
+		metrics.ObserveRequest(RequestMetrics{Host: `a"b`, Method: http.MethodGet, StatusCode: http.StatusOK, Attempts: 1, Duration: duration})
+	}
+
+	var buf strings.Builder
+	_, err := metrics.WriteTo(&buf)
+	c.NoError(err)
+
+	output := buf.String()
+
+	c.Contains(output, `http_client_request_duration_seconds_bucket{host="a\"b",method="GET",status_class="2xx",le="0.1"} 1`+"\n")
+	c.Contains(output, `http_client_request_duration_seconds_bucket{host="a\"b",method="GET",status_class="2xx",le="1"} 2`+"\n")
+	c.Contains(output, `http_client_request_duration_seconds_bucket{host="a\"b",method="GET",status_class="2xx",le="+Inf"} 3`+"\n")
+	c.Contains(output, `http_client_request_duration_seconds_sum{host="a\"b",method="GET",status_class="2xx"} 2.55`+"\n")
+}
+
+func TestRequestMetrics_StatusClass(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		want       string
+	}{
+		{name: "ok", statusCode: http.StatusOK, want: "2xx"},
+		{name: "not found", statusCode: http.StatusNotFound, want: "4xx"},
+		{name: "bad gateway", statusCode: http.StatusBadGateway, want: "5xx"},
+		{name: "no response", statusCode: 0, want: "error"},
+	}
+
+	for _, tt := range tests {
+		c.Equal(tt.want, RequestMetrics{StatusCode: tt.statusCode}.StatusClass(), tt.name)
+	}
+}
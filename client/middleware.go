@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/pokt-foundation/utils-go/id"
+)
+
+const (
+	defaultRequestIDHeader = "X-Request-Id"
+	requestIDLength        = 32
+)
+
+type requestIDKey struct{}
+
+// RoundTripperFunc is an adapter to use a func as an http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls the func with the request
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a round tripper to act on the requests and responses that go through it
+// middlewares must not modify the request they get, but a clone of it
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// Chain wraps the transport with the middlewares in order, the first middleware is the outermost
+// so it's the first to see the request and the last to see the response
+// a nil transport uses http.DefaultTransport
+func Chain(transport http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	if transport == nil {
+		transport = defaultTransport{}
+	}
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+
+	return transport
+}
+
+// defaultTransport looks up http.DefaultTransport on every request, so it can still be swapped after
+// the client is built, like httpmock does on tests
+type defaultTransport struct{}
+
+func (defaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// Use adds middlewares after the ones already set on the client, it must not be called while doing requests
+func (c *Client) Use(middlewares ...Middleware) {
+	c.middlewares = append(c.middlewares, middlewares...)
+	c.Client.Transport = c.chainTransport()
+}
+
+// HeadersMiddleware sets the given headers on every request that doesn't have them already
+func HeadersMiddleware(headers http.Header) Middleware {
+	headers = headers.Clone()
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			cloned := false
+
+			for key, values := range headers {
+				if _, ok := req.Header[key]; ok {
+					continue
+				}
+
+				if !cloned {
+					req, cloned = req.Clone(req.Context()), true
+				}
+
+				req.Header[key] = slices.Clone(values)
+			}
+
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// BearerTokenMiddleware sets a bearer Authorization header with the given token
+func BearerTokenMiddleware(token string) Middleware {
+	return BearerTokenFuncMiddleware(func(context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// BearerTokenFuncMiddleware sets a bearer Authorization header with the token returned by the func
+// on every request, useful for tokens that expire and have to be refreshed
+func BearerTokenFuncMiddleware(tokenFunc func(ctx context.Context) (string, error)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			token, err := tokenFunc(req.Context())
+			if err != nil {
+				closeRequestBody(req)
+				return nil, err
+			}
+
+			return next.RoundTrip(setHeader(req, "Authorization", "Bearer "+token))
+		})
+	}
+}
+
+// UserAgentMiddleware sets the User-Agent header on every request that doesn't have one
+func UserAgentMiddleware(userAgent string) Middleware {
+	return HeadersMiddleware(http.Header{"User-Agent": []string{userAgent}})
+}
+
+// RequestIDMiddleware sets a request ID on the given header (X-Request-Id if empty) of every request
+// that doesn't have one, using the ID on the request context set with ContextWithRequestID
+// or a random one, so all the attempts of a request can share the same ID
+func RequestIDMiddleware(header string) Middleware {
+	if header == "" {
+		header = defaultRequestIDHeader
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(header) != "" {
+				return next.RoundTrip(req)
+			}
+
+			requestID, ok := RequestIDFromContext(req.Context())
+			if !ok {
+				requestID = id.GenerateID(requestIDLength)
+			}
+
+			return next.RoundTrip(setHeader(req, header, requestID))
+		})
+	}
+}
+
+// ContextWithRequestID returns a context carrying the request ID used by RequestIDMiddleware
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID set with ContextWithRequestID
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
+// setHeader returns a clone of the request with the header set
+func setHeader(req *http.Request, key string, values ...string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header[http.CanonicalHeaderKey(key)] = values
+
+	return req
+}
+
+// closeRequestBody closes the body of a request that won't be sent, as round trippers must do
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			*calls = append(*calls, name+" request")
+			resp, err := next.RoundTrip(req)
+			*calls = append(*calls, name+" response")
+			return resp, err
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	c := require.New(t)
+
+	var calls []string
+
+	transport := Chain(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls = append(calls, "transport")
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	}), recordingMiddleware("first", &calls), recordingMiddleware("second", &calls))
+
+	req, err := http.NewRequest(http.MethodGet, "https://dummy.com", nil)
+	c.NoError(err)
+
+	resp, err := transport.RoundTrip(req)
+	c.NoError(err)
+	c.NoError(resp.Body.Close())
+
+	c.Equal([]string{"first request", "second request", "transport", "second response", "first response"}, calls)
+}
+
+func TestClient_Middlewares(t *testing.T) {
+	c := require.New(t)
+
+	var calls []string
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Timeout: time.Second,
+		Middlewares: []Middleware{
+			recordingMiddleware("first", &calls),
+			HeadersMiddleware(http.Header{"X-Static": []string{"ohana"}, "X-Override": []string{"default"}}),
+			UserAgentMiddleware("utils-go"),
+			BearerTokenMiddleware("secret"),
+			RequestIDMiddleware(""),
+		},
+	})
+	client.Use(recordingMiddleware("last", &calls))
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var requestIDs []string
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com", func(req *http.Request) (*http.Response, error) {
+		c.Equal("ohana", req.Header.Get("X-Static"))
+		c.Equal("custom", req.Header.Get("X-Override"))
+		c.Equal("utils-go", req.Header.Get("User-Agent"))
+		c.Equal("Bearer secret", req.Header.Get("Authorization"))
+		requestIDs = append(requestIDs, req.Header.Get("X-Request-Id"))
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"ok": 1}`), nil
+	})
+
+	headers := http.Header{"X-Override": []string{"custom"}}
+
+	response, err := client.GetWithURLAndParams("https://dummy.com", url.Values{}, headers)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+	c.Equal([]string{"first request", "last request", "last response", "first response"}, calls)
+	c.Equal(http.Header{"X-Override": []string{"custom"}}, headers)
+
+	ctx := ContextWithRequestID(context.Background(), "dummy-id")
+
+	response, err = client.GetWithURLAndParamsWithCtx(ctx, "https://dummy.com", url.Values{}, http.Header{"X-Override": []string{"custom"}})
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	c.Len(requestIDs, 2)
+	c.Len(requestIDs[0], requestIDLength)
+	c.Equal("dummy-id", requestIDs[1])
+}
+
+func TestBearerTokenFuncMiddleware(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Timeout: time.Second,
+		Middlewares: []Middleware{
+			BearerTokenFuncMiddleware(func(ctx context.Context) (string, error) {
+				return "", errors.New("token expired")
+			}),
+		},
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	_, err := client.GetWithURLAndParams("https://dummy.com", url.Values{}, http.Header{})
+	c.ErrorContains(err, "token expired")
+	c.Equal(0, httpmock.GetTotalCallCount())
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	c := require.New(t)
+
+	_, ok := RequestIDFromContext(context.Background())
+	c.False(ok)
+
+	requestID, ok := RequestIDFromContext(ContextWithRequestID(context.Background(), "ohana"))
+	c.True(ok)
+	c.Equal("ohana", requestID)
+}
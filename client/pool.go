@@ -0,0 +1,419 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultPoolMaxFailures         = 5
+	defaultPoolEjectionTime        = 30 * time.Second
+	defaultPoolHealthCheckInterval = 10 * time.Second
+	defaultPoolHealthCheckTimeout  = 2 * time.Second
+)
+
+// BalanceStrategy is how a pool picks the endpoint for every request
+type BalanceStrategy int
+
+const (
+	// RoundRobin picks the endpoints in turns
+	RoundRobin BalanceStrategy = iota
+	// LeastOutstanding picks the endpoint with the fewest requests in flight
+	LeastOutstanding
+	// Weighted picks the endpoints in turns proportionally to their weights
+	Weighted
+)
+
+// String returns the strategy name
+func (s BalanceStrategy) String() string {
+	switch s {
+	case RoundRobin:
+		return "round_robin"
+	case LeastOutstanding:
+		return "least_outstanding"
+	case Weighted:
+		return "weighted"
+	default:
+		return "unknown"
+	}
+}
+
+// Endpoint is a base URL the pool sends requests to
+type Endpoint struct {
+	URL string
+	// Weight is used by the weighted strategy, defaults to 1
+	Weight int
+}
+
+// EndpointStatus is the current status of a pool endpoint
+type EndpointStatus struct {
+	URL string
+	// Healthy is false while the endpoint fails its health checks or is ejected
+	Healthy     bool
+	Outstanding int64
+}
+
+// PoolOpts are the options of a pool, zero values use the defaults
+type PoolOpts struct {
+	Endpoints []Endpoint
+	Strategy  BalanceStrategy
+	// MaxFailures is the amount of failures in a row that ejects an endpoint, defaults to 5,
+	// failures are transport errors and 5xx responses
+	MaxFailures int
+	// EjectionTime is how long an ejected endpoint is left out, even if it passes the health checks, defaults to 30 seconds
+	EjectionTime time.Duration
+	// HealthCheckPath enables the active health checks, a GET to the path joined with every endpoint URL
+	// that must answer with a 2xx status for the endpoint to be healthy
+	HealthCheckPath string
+	// HealthCheckInterval defaults to 10 seconds
+	HealthCheckInterval time.Duration
+	// HealthCheckTimeout defaults to 2 seconds
+	HealthCheckTimeout time.Duration
+	// MaxFailovers is the max amount of other endpoints a request is sent to after a failure the client
+	// retry policy allows retrying, defaults to all of them and a negative value disables the failover
+	MaxFailovers int
+}
+
+// Pool spreads the requests across several endpoints, leaving out the unhealthy ones
+// if all of them are unhealthy requests are sent to any of them anyway
+type Pool struct {
+	client       *Client
+	endpoints    []*poolEndpoint
+	strategy     BalanceStrategy
+	maxFailures  int
+	ejectionTime time.Duration
+	maxFailovers int
+	next         atomic.Uint64
+	// mu guards the weighted strategy state
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+	now    func() time.Time
+}
+
+type poolEndpoint struct {
+	base        *url.URL
+	weight      int
+	outstanding atomic.Int64
+
+	mu           sync.Mutex
+	failures     int
+	ejectedUntil time.Time
+	checkFailed  bool
+	// currentWeight is guarded by the pool lock
+	currentWeight int
+}
+
+// NewPool returns a pool sending the requests with the client to the given endpoints
+// it must be closed once done if it has health checks
+func NewPool(client *Client, opts PoolOpts) (*Pool, error) {
+	if len(opts.Endpoints) == 0 {
+		return nil, ErrNoBaseURLs
+	}
+
+	endpoints := make([]*poolEndpoint, len(opts.Endpoints))
+	for i, endpoint := range opts.Endpoints {
+		base, err := url.Parse(endpoint.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		endpoints[i] = &poolEndpoint{base: base, weight: max(endpoint.Weight, 1)}
+	}
+
+	maxFailovers := opts.MaxFailovers
+	if maxFailovers == 0 || maxFailovers >= len(endpoints) {
+		maxFailovers = len(endpoints) - 1
+	}
+
+	p := &Pool{
+		client:       client,
+		endpoints:    endpoints,
+		strategy:     opts.Strategy,
+		maxFailures:  valueOrDefault(opts.MaxFailures, defaultPoolMaxFailures),
+		ejectionTime: valueOrDefault(opts.EjectionTime, defaultPoolEjectionTime),
+		maxFailovers: max(maxFailovers, 0),
+		done:         make(chan struct{}),
+		now:          time.Now,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	if opts.HealthCheckPath == "" {
+		close(p.done)
+		return p, nil
+	}
+
+	checker := &healthChecker{
+		pool:     p,
+		path:     &url.URL{Path: opts.HealthCheckPath},
+		interval: valueOrDefault(opts.HealthCheckInterval, defaultPoolHealthCheckInterval),
+		timeout:  valueOrDefault(opts.HealthCheckTimeout, defaultPoolHealthCheckTimeout),
+	}
+
+	go checker.run(ctx)
+
+	return p, nil
+}
+
+// Do sends the request through DoRequestWithRetries to the picked endpoint, joined with the path and
+// query of the request URL. If it fails with an error or response the client retry policy would retry,
+// it is sent to the next endpoint, up to MaxFailovers times, returning the last result if all fail
+func (p *Pool) Do(req *http.Request) (*http.Response, error) {
+	if err := BufferRequestBody(req); err != nil {
+		return nil, err
+	}
+
+	tried := make(map[*poolEndpoint]bool, len(p.endpoints))
+
+	for {
+		endpoint := p.pick(tried)
+		tried[endpoint] = true
+
+		resp, err := p.send(req, endpoint)
+		if len(tried) > p.maxFailovers || !p.shouldFailover(req, resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			drainAndClose(resp.Body)
+		}
+	}
+}
+
+// Close stops the health checks
+func (p *Pool) Close() {
+	p.cancel()
+	<-p.done
+}
+
+// Endpoints returns the status of every endpoint
+func (p *Pool) Endpoints() []EndpointStatus {
+	now := p.now()
+
+	statuses := make([]EndpointStatus, len(p.endpoints))
+	for i, endpoint := range p.endpoints {
+		statuses[i] = EndpointStatus{
+			URL:         endpoint.base.String(),
+			Healthy:     endpoint.healthy(now),
+			Outstanding: endpoint.outstanding.Load(),
+		}
+	}
+
+	return statuses
+}
+
+func (p *Pool) shouldFailover(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	return errors.Is(err, ErrCircuitOpen) || p.client.retryPolicy(req, resp, err)
+}
+
+// send does the request to the endpoint tracking its outstanding requests until the response body is closed
+func (p *Pool) send(req *http.Request, endpoint *poolEndpoint) (*http.Response, error) {
+	targetReq, err := requestForTarget(req.Context(), req, rebaseURL(endpoint.base, req.URL))
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.outstanding.Add(1)
+
+	resp, err := p.client.DoRequestWithRetries(targetReq)
+	endpoint.observe(circuitResultFor(resp, err), p.maxFailures, p.now().Add(p.ejectionTime))
+
+	if resp == nil {
+		endpoint.outstanding.Add(-1)
+		return nil, err
+	}
+
+	var once sync.Once
+
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: func() {
+		once.Do(func() { endpoint.outstanding.Add(-1) })
+	}}
+
+	return resp, err
+}
+
+// pick returns the endpoint for the next request among the healthy ones not tried yet,
+// or among all the ones not tried yet if none of them is healthy
+func (p *Pool) pick(tried map[*poolEndpoint]bool) *poolEndpoint {
+	now := p.now()
+
+	candidates := make([]*poolEndpoint, 0, len(p.endpoints))
+	for _, endpoint := range p.endpoints {
+		if !tried[endpoint] && endpoint.healthy(now) {
+			candidates = append(candidates, endpoint)
+		}
+	}
+
+	if len(candidates) == 0 {
+		for _, endpoint := range p.endpoints {
+			if !tried[endpoint] {
+				candidates = append(candidates, endpoint)
+			}
+		}
+	}
+
+	switch p.strategy {
+	case LeastOutstanding:
+		return p.leastOutstanding(candidates)
+	case Weighted:
+		return p.weighted(candidates)
+	default:
+		return candidates[p.turn()%uint64(len(candidates))]
+	}
+}
+
+// turn returns the number of the pick, starting at zero
+func (p *Pool) turn() uint64 {
+	return p.next.Add(1) - 1
+}
+
+// leastOutstanding returns the candidate with the fewest requests in flight, ties are taken in turns
+func (p *Pool) leastOutstanding(candidates []*poolEndpoint) *poolEndpoint {
+	offset := int(p.turn() % uint64(len(candidates)))
+
+	var picked *poolEndpoint
+	for i := range candidates {
+		candidate := candidates[(offset+i)%len(candidates)]
+		if picked == nil || candidate.outstanding.Load() < picked.outstanding.Load() {
+			picked = candidate
+		}
+	}
+
+	return picked
+}
+
+// weighted picks the candidates with the smooth weighted round robin used by nginx
+func (p *Pool) weighted(candidates []*poolEndpoint) *poolEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var (
+		picked *poolEndpoint
+		total  int
+	)
+
+	for _, candidate := range candidates {
+		candidate.currentWeight += candidate.weight
+		total += candidate.weight
+
+		if picked == nil || candidate.currentWeight > picked.currentWeight {
+			picked = candidate
+		}
+	}
+
+	picked.currentWeight -= total
+
+	return picked
+}
+
+// healthy checks if the endpoint passed its last health check and is not ejected
+func (e *poolEndpoint) healthy(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return !e.checkFailed && !now.Before(e.ejectedUntil)
+}
+
+// observe counts the failures in a row, ejecting the endpoint until the given time once they reach maxFailures
+func (e *poolEndpoint) observe(result circuitResult, maxFailures int, ejectUntil time.Time) {
+	if result == circuitIgnored {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if result == circuitSuccess {
+		e.failures = 0
+		return
+	}
+
+	e.failures++
+	if e.failures >= maxFailures {
+		e.failures = 0
+		e.ejectedUntil = ejectUntil
+	}
+}
+
+// setCheckResult records the health check result, an ejected endpoint stays out until its ejection ends
+// even if it passes the check, as the requests can fail on paths the check doesn't cover
+func (e *poolEndpoint) setCheckResult(healthy bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.checkFailed = !healthy
+}
+
+type healthChecker struct {
+	pool     *Pool
+	path     *url.URL
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// run checks all the endpoints right away and then on every interval until the context is done
+func (h *healthChecker) run(ctx context.Context) {
+	defer close(h.pool.done)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.checkAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *healthChecker) checkAll(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, endpoint := range h.pool.endpoints {
+		wg.Add(1)
+
+		go func(endpoint *poolEndpoint) {
+			defer wg.Done()
+
+			healthy := h.check(ctx, endpoint)
+			if ctx.Err() == nil {
+				endpoint.setCheckResult(healthy)
+			}
+		}(endpoint)
+	}
+
+	wg.Wait()
+}
+
+// check sends a single health check request, without retries
+func (h *healthChecker) check(ctx context.Context, endpoint *poolEndpoint) bool {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rebaseURL(endpoint.base, h.path).String(), nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := h.pool.client.Client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer drainAndClose(resp.Body)
+
+	return resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices
+}
@@ -0,0 +1,287 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+// registerPoolResponders mocks the relay endpoint of every host, answering with the given status
+// and counting the requests received by each one
+func registerPoolResponders(statuses map[string]int) func() map[string]int {
+	var mu sync.Mutex
+
+	counts := make(map[string]int)
+
+	for host, status := range statuses {
+		host, status := host, status
+
+		httpmock.RegisterResponder(http.MethodPost, "https://"+host+"/v1/relay", func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			counts[host]++
+			mu.Unlock()
+
+			if status == 0 {
+				return nil, syscall.ECONNREFUSED
+			}
+
+			return httpmock.NewStringResponse(status, `{"host": "`+host+`"}`), nil
+		})
+	}
+
+	return func() map[string]int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return counts
+	}
+}
+
+func doPoolRequests(c *require.Assertions, pool *Pool, amount int) []*http.Response {
+	responses := make([]*http.Response, amount)
+
+	for i := range responses {
+		req, err := http.NewRequest(http.MethodPost, "/relay", strings.NewReader(`{"method":"eth_blockNumber"}`))
+		c.NoError(err)
+
+		// transport errors are failed over just for idempotent requests
+		req.Header.Set("Idempotency-Key", "ohana")
+
+		responses[i], err = pool.Do(req)
+		c.NoError(err)
+	}
+
+	return responses
+}
+
+func closeResponses(c *require.Assertions, responses []*http.Response) {
+	for _, response := range responses {
+		c.NoError(response.Body.Close())
+	}
+}
+
+func TestPool_Strategies(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	tests := []struct {
+		name       string
+		strategy   BalanceStrategy
+		weights    []int
+		wantCounts map[string]int
+	}{
+		{
+			name:       "round robin",
+			strategy:   RoundRobin,
+			wantCounts: map[string]int{"a.com": 4, "b.com": 4, "c.com": 4},
+		},
+		{
+			name:       "weighted",
+			strategy:   Weighted,
+			weights:    []int{4, 2, 0},
+			wantCounts: map[string]int{"a.com": 8, "b.com": 4, "c.com": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		httpmock.Reset()
+
+		counts := registerPoolResponders(map[string]int{"a.com": http.StatusOK, "b.com": http.StatusOK, "c.com": http.StatusOK})
+
+		endpoints := []Endpoint{{URL: "https://a.com/v1"}, {URL: "https://b.com/v1"}, {URL: "https://c.com/v1"}}
+		for i, weight := range tt.weights {
+			endpoints[i].Weight = weight
+		}
+
+		pool, err := NewPool(NewDefaultClient(), PoolOpts{Endpoints: endpoints, Strategy: tt.strategy})
+		c.NoError(err, tt.name)
+
+		total := 0
+		for _, count := range tt.wantCounts {
+			total += count
+		}
+
+		closeResponses(c, doPoolRequests(c, pool, total))
+		c.Equal(tt.wantCounts, counts(), tt.name)
+
+		pool.Close()
+	}
+}
+
+func TestPool_LeastOutstanding(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	counts := registerPoolResponders(map[string]int{"a.com": http.StatusOK, "b.com": http.StatusOK})
+
+	pool, err := NewPool(NewDefaultClient(), PoolOpts{
+		Endpoints: []Endpoint{{URL: "https://a.com/v1"}, {URL: "https://b.com/v1"}},
+		Strategy:  LeastOutstanding,
+	})
+	c.NoError(err)
+	defer pool.Close()
+
+	// The requests are outstanding until their bodies are closed
+	responses := doPoolRequests(c, pool, 4)
+	c.Equal(map[string]int{"a.com": 2, "b.com": 2}, counts())
+
+	for _, status := range pool.Endpoints() {
+		c.Equal(int64(2), status.Outstanding)
+	}
+
+	closeResponses(c, responses[:2])
+	closeResponses(c, responses[:2])
+
+	first := pool.Endpoints()
+	c.Equal(int64(2), first[0].Outstanding+first[1].Outstanding)
+
+	closeResponses(c, responses[2:])
+
+	for _, status := range pool.Endpoints() {
+		c.Equal(int64(0), status.Outstanding)
+	}
+}
+
+func TestPool_FailoverAndEjection(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	counts := registerPoolResponders(map[string]int{"a.com": 0, "b.com": http.StatusServiceUnavailable, "c.com": http.StatusOK})
+
+	pool, err := NewPool(NewDefaultClient(), PoolOpts{
+		Endpoints:    []Endpoint{{URL: "https://a.com/v1"}, {URL: "https://b.com/v1"}, {URL: "https://c.com/v1"}},
+		MaxFailures:  1,
+		EjectionTime: time.Minute,
+	})
+	c.NoError(err)
+	defer pool.Close()
+
+	// Every request fails over until it reaches the healthy endpoint
+	responses := doPoolRequests(c, pool, 3)
+	for _, response := range responses {
+		c.Equal(http.StatusOK, response.StatusCode)
+	}
+	closeResponses(c, responses)
+
+	c.Equal(3, counts()["c.com"])
+
+	statuses := pool.Endpoints()
+	c.False(statuses[0].Healthy)
+	c.False(statuses[1].Healthy)
+	c.True(statuses[2].Healthy)
+
+	// Ejected endpoints are left out
+	before := counts()["a.com"] + counts()["b.com"]
+	closeResponses(c, doPoolRequests(c, pool, 3))
+	c.Equal(before, counts()["a.com"]+counts()["b.com"])
+
+	// Once the ejection ends they are picked again
+	pool.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	c.True(pool.Endpoints()[0].Healthy)
+
+	// Non retryable responses are not sent to other endpoints
+	httpmock.RegisterResponder(http.MethodPost, "https://c.com/v1/relay", httpmock.NewStringResponder(http.StatusBadRequest, `{}`))
+
+	pool, err = NewPool(NewDefaultClient(), PoolOpts{Endpoints: []Endpoint{{URL: "https://c.com/v1"}, {URL: "https://b.com/v1"}}})
+	c.NoError(err)
+
+	before = counts()["b.com"]
+
+	responses = doPoolRequests(c, pool, 1)
+	c.Equal(http.StatusBadRequest, responses[0].StatusCode)
+	c.Equal(before, counts()["b.com"])
+	closeResponses(c, responses)
+
+	// Neither are any responses with the failover disabled
+	pool, err = NewPool(NewDefaultClient(), PoolOpts{
+		Endpoints:    []Endpoint{{URL: "https://b.com/v1"}, {URL: "https://c.com/v1"}},
+		MaxFailovers: -1,
+	})
+	c.NoError(err)
+
+	responses = doPoolRequests(c, pool, 1)
+	c.Equal(http.StatusServiceUnavailable, responses[0].StatusCode)
+	closeResponses(c, responses)
+
+	_, err = NewPool(NewDefaultClient(), PoolOpts{})
+	c.ErrorIs(err, ErrNoBaseURLs)
+}
+
+func TestPool_HealthChecks(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	counts := registerPoolResponders(map[string]int{"a.com": http.StatusOK, "b.com": http.StatusOK})
+
+	httpmock.RegisterResponder(http.MethodGet, "https://a.com/v1/health", httpmock.NewStringResponder(http.StatusInternalServerError, `{}`))
+	httpmock.RegisterResponder(http.MethodGet, "https://b.com/v1/health", httpmock.NewStringResponder(http.StatusOK, `{}`))
+
+	pool, err := NewPool(NewDefaultClient(), PoolOpts{
+		Endpoints:           []Endpoint{{URL: "https://a.com/v1"}, {URL: "https://b.com/v1"}},
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 10 * time.Millisecond,
+	})
+	c.NoError(err)
+
+	c.Eventually(func() bool {
+		return !pool.Endpoints()[0].Healthy
+	}, time.Second, 5*time.Millisecond)
+
+	closeResponses(c, doPoolRequests(c, pool, 4))
+	c.Equal(map[string]int{"b.com": 4}, counts())
+
+	// The endpoint is healthy again once it passes a check
+	httpmock.RegisterResponder(http.MethodGet, "https://a.com/v1/health", httpmock.NewStringResponder(http.StatusOK, `{}`))
+
+	c.Eventually(func() bool {
+		return pool.Endpoints()[0].Healthy
+	}, time.Second, 5*time.Millisecond)
+
+	pool.Close()
+
+	calls := httpmock.GetTotalCallCount()
+	time.Sleep(30 * time.Millisecond)
+	c.Equal(calls, httpmock.GetTotalCallCount())
+}
+
+func TestPoolEndpoint_Observe(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Now()
+	endpoint := &poolEndpoint{}
+
+	// Failures must be in a row to eject the endpoint
+	for _, result := range []circuitResult{circuitFailure, circuitSuccess, circuitFailure, circuitIgnored} {
+		endpoint.observe(result, 2, now.Add(time.Minute))
+	}
+	c.True(endpoint.healthy(now))
+
+	endpoint.observe(circuitFailure, 2, now.Add(time.Minute))
+	c.False(endpoint.healthy(now))
+	c.True(endpoint.healthy(now.Add(time.Minute)))
+
+	// A passed health check doesn't end the ejection
+	endpoint.setCheckResult(true)
+	c.False(endpoint.healthy(now))
+	c.True(endpoint.healthy(now.Add(time.Minute)))
+
+	endpoint.setCheckResult(false)
+	c.False(endpoint.healthy(now.Add(time.Hour)))
+
+	endpoint.setCheckResult(true)
+	c.True(endpoint.healthy(now.Add(time.Hour)))
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// unixTimestampThreshold tells apart X-RateLimit-Reset values sent as unix timestamps from the ones sent as seconds
+const unixTimestampThreshold = 1_000_000_000
+
+// RateLimit is a token bucket limit, requests wait for a token before being sent
+type RateLimit struct {
+	// RequestsPerSecond is the rate the bucket is refilled
+	RequestsPerSecond float64
+	// Burst is the max amount of requests that can be sent at once, defaults to 1
+	Burst int
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	burst := float64(max(limit.Burst, 1))
+
+	return &tokenBucket{
+		rate:   limit.RequestsPerSecond,
+		burst:  burst,
+		tokens: burst,
+		last:   now,
+	}
+}
+
+// reserve takes a token and returns how long to wait before it can be used
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+
+	b.tokens--
+
+	if b.tokens >= 0 || b.rate <= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel gives back a reserved token that was not used
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+type rateLimiter struct {
+	global   *tokenBucket
+	hosts    map[string]*tokenBucket
+	adaptive bool
+
+	mu     sync.Mutex
+	paused map[string]time.Time
+	now    func() time.Time
+}
+
+func newRateLimiter(global *RateLimit, hosts map[string]RateLimit, adaptive bool) *rateLimiter {
+	now := time.Now()
+
+	limiter := &rateLimiter{
+		hosts:    make(map[string]*tokenBucket, len(hosts)),
+		adaptive: adaptive,
+		paused:   make(map[string]time.Time),
+		now:      time.Now,
+	}
+
+	if global != nil {
+		limiter.global = newTokenBucket(*global, now)
+	}
+
+	for host, limit := range hosts {
+		limiter.hosts[host] = newTokenBucket(limit, now)
+	}
+
+	return limiter
+}
+
+// wait blocks until the request to the host can be sent or the context is done
+func (l *rateLimiter) wait(ctx context.Context, host string) error {
+	if err := sleepWithContext(ctx, l.pausedFor(host)); err != nil {
+		return err
+	}
+
+	for _, bucket := range []*tokenBucket{l.global, l.hosts[host]} {
+		if bucket == nil {
+			continue
+		}
+
+		if err := sleepWithContext(ctx, bucket.reserve(l.now())); err != nil {
+			bucket.cancel()
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *rateLimiter) pausedFor(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until, ok := l.paused[host]
+	if !ok {
+		return 0
+	}
+
+	wait := until.Sub(l.now())
+	if wait <= 0 {
+		delete(l.paused, host)
+	}
+
+	return wait
+}
+
+// observe pauses the requests to the host when the response says the server limit was reached
+// either with X-RateLimit-Remaining set to zero or a Retry-After header on a 429 response
+func (l *rateLimiter) observe(host string, resp *http.Response) {
+	if !l.adaptive || resp == nil {
+		return
+	}
+
+	now := l.now()
+
+	wait, ok := rateLimitReset(resp.Header, now)
+	if !ok && resp.StatusCode == http.StatusTooManyRequests {
+		wait, ok = parseRetryAfter(resp.Header.Get("Retry-After"), now)
+	}
+	if !ok || wait <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := now.Add(wait); until.After(l.paused[host]) {
+		l.paused[host] = until
+	}
+}
+
+// rateLimitReset returns how long to wait when X-RateLimit-Remaining is zero
+// X-RateLimit-Reset can be either seconds to wait or a unix timestamp, falling back to Retry-After
+func rateLimitReset(header http.Header, now time.Time) (time.Duration, bool) {
+	if header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || reset < 0 {
+		return parseRetryAfter(header.Get("Retry-After"), now)
+	}
+
+	if reset < unixTimestampThreshold {
+		return time.Duration(reset) * time.Second, true
+	}
+
+	return time.Unix(reset, 0).Sub(now), true
+}
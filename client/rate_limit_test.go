@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pokt-foundation/utils-go/mock-client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Now()
+	bucket := newTokenBucket(RateLimit{RequestsPerSecond: 10, Burst: 2}, now)
+
+	c.Equal(time.Duration(0), bucket.reserve(now))
+	c.Equal(time.Duration(0), bucket.reserve(now))
+	c.Equal(100*time.Millisecond, bucket.reserve(now))
+	c.Equal(200*time.Millisecond, bucket.reserve(now))
+
+	bucket.cancel()
+	c.Equal(200*time.Millisecond, bucket.reserve(now))
+
+	// Refill is capped at the burst
+	now = now.Add(time.Hour)
+	c.Equal(time.Duration(0), bucket.reserve(now))
+	c.Equal(time.Duration(0), bucket.reserve(now))
+	c.Equal(100*time.Millisecond, bucket.reserve(now))
+}
+
+func TestRateLimitReset(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name          string
+		header        http.Header
+		expectedDelay time.Duration
+		expectedOk    bool
+	}{
+		{name: "remaining requests", header: http.Header{"X-Ratelimit-Remaining": []string{"3"}, "X-Ratelimit-Reset": []string{"10"}}},
+		{name: "reset in seconds", header: http.Header{"X-Ratelimit-Remaining": []string{"0"}, "X-Ratelimit-Reset": []string{"10"}}, expectedDelay: 10 * time.Second, expectedOk: true},
+		{name: "reset as timestamp", header: http.Header{"X-Ratelimit-Remaining": []string{"0"}, "X-Ratelimit-Reset": []string{"1700000005"}}, expectedDelay: 5 * time.Second, expectedOk: true},
+		{name: "retry after fallback", header: http.Header{"X-Ratelimit-Remaining": []string{"0"}, "Retry-After": []string{"2"}}, expectedDelay: 2 * time.Second, expectedOk: true},
+		{name: "no reset", header: http.Header{"X-Ratelimit-Remaining": []string{"0"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delay, ok := rateLimitReset(test.header, now)
+			c.Equal(test.expectedOk, ok)
+			c.Equal(test.expectedDelay, delay)
+		})
+	}
+}
+
+func TestRateLimiter_Adaptive(t *testing.T) {
+	c := require.New(t)
+
+	limiter := newRateLimiter(nil, nil, true)
+
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	limiter.observe("dummy.com", &http.Response{StatusCode: http.StatusOK, Header: http.Header{}})
+	c.Equal(time.Duration(0), limiter.pausedFor("dummy.com"))
+
+	limiter.observe("dummy.com", &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"3"}}})
+	c.Equal(3*time.Second, limiter.pausedFor("dummy.com"))
+	c.Equal(time.Duration(0), limiter.pausedFor("other.com"))
+
+	limiter.observe("dummy.com", &http.Response{StatusCode: http.StatusOK, Header: http.Header{
+		"X-Ratelimit-Remaining": []string{"0"},
+		"X-Ratelimit-Reset":     []string{"1"},
+	}})
+	c.Equal(3*time.Second, limiter.pausedFor("dummy.com"))
+
+	now = now.Add(3 * time.Second)
+	c.Equal(time.Duration(0), limiter.pausedFor("dummy.com"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	limiter.observe("dummy.com", &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"3"}}})
+	c.ErrorIs(limiter.wait(ctx, "dummy.com"), context.Canceled)
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Timeout:   time.Second,
+		RateLimit: &RateLimit{RequestsPerSecond: 1000},
+		HostRateLimits: map[string]RateLimit{
+			"slow.com": {RequestsPerSecond: 20, Burst: 1},
+		},
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mock.AddMockedResponse(http.MethodGet, "https://slow.com", http.StatusOK, `{"ok": 1}`)
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		response, err := client.GetWithURLAndParams("https://slow.com", url.Values{}, http.Header{})
+		c.NoError(err)
+		c.NoError(response.Body.Close())
+	}
+
+	c.GreaterOrEqual(time.Since(start), 90*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	client = NewCustomClientWithOptions(CustomClientOpts{
+		Timeout:   time.Second,
+		RateLimit: &RateLimit{RequestsPerSecond: 0.001, Burst: 1},
+	})
+
+	response, err := client.GetWithURLAndParams("https://slow.com", url.Values{}, http.Header{})
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	_, err = client.GetWithURLAndParamsWithCtx(ctx, "https://slow.com", url.Values{}, http.Header{})
+	c.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func TestClient_AdaptiveRateLimit(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Timeout:           time.Second,
+		AdaptiveRateLimit: true,
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com", func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, `{"ok": 1}`)
+		resp.Header.Set("X-RateLimit-Remaining", "0")
+		resp.Header.Set("X-RateLimit-Reset", strconv.Itoa(1))
+		return resp, nil
+	})
+
+	start := time.Now()
+
+	for i := 0; i < 2; i++ {
+		response, err := client.GetWithURLAndParams("https://dummy.com", url.Values{}, http.Header{})
+		c.NoError(err)
+		c.NoError(response.Body.Close())
+	}
+
+	c.GreaterOrEqual(time.Since(start), 900*time.Millisecond)
+}
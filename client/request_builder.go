@@ -0,0 +1,252 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	contentTypeJSON = "application/json"
+	contentTypeForm = "application/x-www-form-urlencoded"
+)
+
+// MultipartFile is a file sent on a multipart body
+type MultipartFile struct {
+	FieldName string
+	FileName  string
+	// ContentType defaults to application/octet-stream
+	ContentType string
+	Content     io.Reader
+}
+
+// RequestBuilder builds a request step by step and sends it through the client retry pipeline
+// the values given to it are copied, so they are never modified
+// the first error found while building is returned by Build or Do
+type RequestBuilder struct {
+	client      *Client
+	method      string
+	rawURL      string
+	pathParams  map[string]string
+	query       url.Values
+	headers     http.Header
+	body        io.Reader
+	contentType string
+	err         error
+}
+
+// NewRequest starts building a request with the given method and URL
+// the URL can have path params in braces, like /users/{id}, to be set with PathParam
+// relative URLs are joined with the client base URL
+func (c *Client) NewRequest(method, rawURL string) *RequestBuilder {
+	return &RequestBuilder{
+		client:     c,
+		method:     method,
+		rawURL:     rawURL,
+		pathParams: make(map[string]string),
+		query:      url.Values{},
+		headers:    http.Header{},
+	}
+}
+
+// PathParam sets the value of the path param in braces on the URL, the value is escaped
+func (b *RequestBuilder) PathParam(key, value string) *RequestBuilder {
+	b.pathParams[key] = value
+	return b
+}
+
+// Query adds the value to the query param
+func (b *RequestBuilder) Query(key, value string) *RequestBuilder {
+	b.query.Add(key, value)
+	return b
+}
+
+// QueryParams adds all the given values to the query params
+func (b *RequestBuilder) QueryParams(params url.Values) *RequestBuilder {
+	for key, values := range params {
+		b.query[key] = append(b.query[key], values...)
+	}
+
+	return b
+}
+
+// Header sets the header value
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	b.headers.Set(key, value)
+	return b
+}
+
+// Headers adds all the given header values
+func (b *RequestBuilder) Headers(headers http.Header) *RequestBuilder {
+	for key, values := range headers {
+		key = http.CanonicalHeaderKey(key)
+		b.headers[key] = append(b.headers[key], values...)
+	}
+
+	return b
+}
+
+// JSONBody sets the value encoded as JSON as body, a nil value sends no body
+// but the Content-Type is still set to application/json
+func (b *RequestBuilder) JSONBody(value any) *RequestBuilder {
+	body, err := getJSONBodyFromParams(value)
+	if err != nil {
+		return b.fail(err)
+	}
+
+	return b.setBody(body, contentTypeJSON)
+}
+
+// FormBody sets the values URL encoded as body, empty values send no body
+func (b *RequestBuilder) FormBody(values url.Values) *RequestBuilder {
+	var body io.Reader
+	if len(values) != 0 {
+		body = strings.NewReader(values.Encode())
+	}
+
+	return b.setBody(body, contentTypeForm)
+}
+
+// MultipartBody sets a multipart/form-data body with the given fields and files
+// the files are read right away, the body is kept in memory so it can be sent again on retries
+func (b *RequestBuilder) MultipartBody(fields map[string]string, files ...MultipartFile) *RequestBuilder {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := writer.WriteField(key, fields[key]); err != nil {
+			return b.fail(err)
+		}
+	}
+
+	for _, file := range files {
+		if err := writeMultipartFile(writer, file); err != nil {
+			return b.fail(err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return b.fail(err)
+	}
+
+	return b.setBody(bytes.NewReader(buf.Bytes()), writer.FormDataContentType())
+}
+
+// RawBody sets the reader as body with the given content type, it is only read when the request is sent
+// so it can be a stream, it is buffered in memory just if the client has retries
+func (b *RequestBuilder) RawBody(body io.Reader, contentType string) *RequestBuilder {
+	return b.setBody(body, contentType)
+}
+
+// Build returns the request with the given context
+func (b *RequestBuilder) Build(ctx context.Context) (*http.Request, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	reqURL, err := b.url()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, b.method, reqURL, b.body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = b.headers.Clone()
+
+	if b.contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", b.contentType)
+	}
+
+	return req, nil
+}
+
+// Do builds the request and sends it with DoRequestWithRetries
+func (b *RequestBuilder) Do(ctx context.Context) (*http.Response, error) {
+	req, err := b.Build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.client.DoRequestWithRetries(req)
+}
+
+// url returns the URL with its path params replaced and the query params added
+func (b *RequestBuilder) url() (string, error) {
+	rawURL := b.rawURL
+	for key, value := range b.pathParams {
+		rawURL = strings.ReplaceAll(rawURL, "{"+key+"}", url.PathEscape(value))
+	}
+
+	reqURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	reqURL, err = b.client.resolveURL(reqURL)
+	if err != nil {
+		return "", err
+	}
+
+	reqURL.RawQuery = appendQuery(reqURL.RawQuery, b.query)
+
+	return reqURL.String(), nil
+}
+
+func (b *RequestBuilder) setBody(body io.Reader, contentType string) *RequestBuilder {
+	b.body = body
+	b.contentType = contentType
+
+	return b
+}
+
+// fail keeps the first error found while building
+func (b *RequestBuilder) fail(err error) *RequestBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+
+	return b
+}
+
+func writeMultipartFile(writer *multipart.Writer, file MultipartFile) error {
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(file.FieldName), escapeQuotes(file.FileName)))
+	header.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, file.Content)
+
+	return err
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
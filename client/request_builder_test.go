@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestBuilder_Build(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+
+	headers := http.Header{"X-Dummy": []string{"ohana"}}
+	params := url.Values{"family": []string{"ohana"}}
+
+	req, err := client.NewRequest(http.MethodPatch, "https://dummy.com/users/{id}/posts/{post}?page=1").
+		PathParam("id", "a b").
+		PathParam("post", "7").
+		QueryParams(params).
+		Query("family", "means").
+		Headers(headers).
+		Header("X-Other", "family").
+		JSONBody(map[string]string{"ohana": "family"}).
+		Build(context.Background())
+	c.NoError(err)
+
+	c.Equal(http.MethodPatch, req.Method)
+	c.Equal("https://dummy.com/users/a%20b/posts/7?page=1&family=ohana&family=means", req.URL.String())
+	c.Equal("ohana", req.Header.Get("X-Dummy"))
+	c.Equal("family", req.Header.Get("X-Other"))
+	c.Equal("application/json", req.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(req.Body)
+	c.NoError(err)
+	c.Equal(`{"ohana":"family"}`, string(body))
+
+	// Inputs are never modified
+	c.Equal(http.Header{"X-Dummy": []string{"ohana"}}, headers)
+	c.Equal(url.Values{"family": []string{"ohana"}}, params)
+}
+
+func TestRequestBuilder_KeepsRawQuery(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+
+	req, err := client.NewRequest(http.MethodGet, "https://dummy.com?z=1&a=%zz&sig=a+b").
+		Query("page", "2").
+		Build(context.Background())
+	c.NoError(err)
+	c.Equal("z=1&a=%zz&sig=a+b&page=2", req.URL.RawQuery)
+}
+
+func TestRequestBuilder_Bodies(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+
+	req, err := client.NewRequest(http.MethodPost, "https://dummy.com").
+		FormBody(url.Values{"ohana": []string{"family"}}).
+		Build(context.Background())
+	c.NoError(err)
+	c.Equal("application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+	c.NoError(req.ParseForm())
+	c.Equal("family", req.PostForm.Get("ohana"))
+
+	req, err = client.NewRequest(http.MethodPost, "https://dummy.com").
+		Header("Content-Type", "text/csv").
+		RawBody(strings.NewReader("ohana,family"), "text/plain").
+		Build(context.Background())
+	c.NoError(err)
+	c.Equal("text/csv", req.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(req.Body)
+	c.NoError(err)
+	c.Equal("ohana,family", string(body))
+
+	req, err = client.NewRequest(http.MethodPost, "https://dummy.com").
+		MultipartBody(map[string]string{"ohana": "family"}, MultipartFile{
+			FieldName: "file",
+			FileName:  "dummy.json",
+			Content:   strings.NewReader(`{"ohana": "means family"}`),
+		}).
+		Build(context.Background())
+	c.NoError(err)
+	c.NoError(req.ParseMultipartForm(1 << 20))
+	c.Equal("family", req.MultipartForm.Value["ohana"][0])
+
+	file := req.MultipartForm.File["file"][0]
+	c.Equal("dummy.json", file.Filename)
+	c.Equal("application/octet-stream", file.Header.Get("Content-Type"))
+
+	_, err = client.NewRequest(http.MethodPost, "https://dummy.com").
+		JSONBody(make(chan int)).
+		Build(context.Background())
+	c.Error(err)
+
+	_, err = client.NewRequest(http.MethodPost, "://dummy.com").Build(context.Background())
+	c.Error(err)
+}
+
+func TestRequestBuilder_Do(t *testing.T) {
+	c := require.New(t)
+
+	client := NewDefaultClient()
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodDelete, "https://dummy.com/users/1", func(req *http.Request) (*http.Response, error) {
+		c.Equal("true", req.URL.Query().Get("force"))
+		return httpmock.NewStringResponse(http.StatusNoContent, ""), nil
+	})
+
+	response, err := client.NewRequest(http.MethodDelete, "https://dummy.com/users/{id}").
+		PathParam("id", "1").
+		Query("force", "true").
+		Do(context.Background())
+	c.NoError(err)
+	c.Equal(http.StatusNoContent, response.StatusCode)
+	c.NoError(response.Body.Close())
+
+	_, err = client.NewRequest(http.MethodGet, "://dummy.com").Do(context.Background())
+	c.Error(err)
+}
+
+func TestRequestBuilder_MultipartRetries(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries: 1,
+		Backoff: ConstantBackoff(0),
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var files []string
+
+	httpmock.RegisterResponder(http.MethodPost, "https://dummy.com/upload", func(req *http.Request) (*http.Response, error) {
+		reader, err := req.MultipartReader()
+		c.NoError(err)
+
+		part, err := reader.NextPart()
+		c.NoError(err)
+
+		data, err := io.ReadAll(part)
+		c.NoError(err)
+		files = append(files, string(data))
+
+		_, err = reader.NextPart()
+		c.ErrorIs(err, io.EOF)
+
+		if len(files) == 1 {
+			return httpmock.NewStringResponse(http.StatusBadGateway, ""), nil
+		}
+
+		return httpmock.NewStringResponse(http.StatusCreated, ""), nil
+	})
+
+	response, err := client.NewRequest(http.MethodPost, "https://dummy.com/upload").
+		MultipartBody(nil, MultipartFile{FieldName: "file", FileName: "a.txt", ContentType: "text/plain", Content: strings.NewReader("ohana")}).
+		Do(context.Background())
+	c.NoError(err)
+	c.Equal(http.StatusCreated, response.StatusCode)
+	c.NoError(response.Body.Close())
+	c.Equal([]string{"ohana", "ohana"}, files)
+}
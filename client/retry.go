@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// RetryPolicy decides if a request attempt should be retried given the request and its response or transport error
+// resp is nil when err is not nil
+type RetryPolicy func(req *http.Request, resp *http.Response, err error) bool
+
+// DefaultRetryPolicy retries 5xx and 429 responses, and transient transport errors just for idempotent requests
+// as the request may have reached the server before failing, so sending it again could apply it twice
+func DefaultRetryPolicy(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return IsIdempotent(req) && IsRetryableError(err)
+	}
+
+	return resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// IsIdempotent returns true for requests that have the same effect when sent many times as when sent once,
+// the ones with GET, HEAD, OPTIONS, PUT or DELETE methods or with an Idempotency-Key header
+func IsIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return req.Header.Get(idempotencyKeyHeader) != ""
+}
+
+// IsRetryableError returns true for transport errors that are likely to succeed on a new attempt
+// such as timeouts, connection resets or refusals and DNS failures
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// parseRetryAfter parses the Retry-After header value, that can be either seconds or an HTTP-date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+
+	return 0, true
+}
+
+// retryAfterFromResponse gets the wait asked by the server on 429 and 503 responses
+func retryAfterFromResponse(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	return parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+}
+
+// nextDelay returns the wait before the next attempt, honoring the Retry-After header if present
+// returns false if the server asked to wait longer than the max allowed
+func (c *Client) nextDelay(attempt int, previous time.Duration, resp *http.Response) (time.Duration, bool) {
+	delay := c.backoff(attempt, previous)
+
+	retryAfter, ok := retryAfterFromResponse(resp)
+	if !ok {
+		return delay, true
+	}
+
+	if c.maxRetryAfter > 0 && retryAfter > c.maxRetryAfter {
+		return 0, false
+	}
+
+	return max(delay, retryAfter), true
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	c := require.New(t)
+
+	timeoutErr := &url.Error{Op: "Get", URL: "https://dummy.com", Err: timeoutError{}}
+
+	tests := []struct {
+		name     string
+		method   string
+		header   http.Header
+		resp     *http.Response
+		err      error
+		expected bool
+	}{
+		{name: "ok response", resp: &http.Response{StatusCode: http.StatusOK}, expected: false},
+		{name: "bad request", resp: &http.Response{StatusCode: http.StatusBadRequest}, expected: false},
+		{name: "too many requests", resp: &http.Response{StatusCode: http.StatusTooManyRequests}, expected: true},
+		{name: "internal server error", resp: &http.Response{StatusCode: http.StatusInternalServerError}, expected: true},
+		{name: "bad gateway", resp: &http.Response{StatusCode: http.StatusBadGateway}, expected: true},
+		{name: "timeout", err: timeoutErr, expected: true},
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, expected: true},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, expected: true},
+		{name: "dns failure", err: &net.DNSError{Err: "no such host", Name: "dummy.com"}, expected: true},
+		{name: "unexpected eof", err: fmt.Errorf("reading: %w", io.ErrUnexpectedEOF), expected: true},
+		{name: "context canceled", err: &url.Error{Op: "Get", URL: "https://dummy.com", Err: context.Canceled}, expected: false},
+		{name: "unknown error", err: errors.New("dummy"), expected: false},
+		{name: "post bad gateway", method: http.MethodPost, resp: &http.Response{StatusCode: http.StatusBadGateway}, expected: true},
+		{name: "post timeout", method: http.MethodPost, err: timeoutErr, expected: false},
+		{name: "patch connection reset", method: http.MethodPatch, err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, expected: false},
+		{name: "post timeout with idempotency key", method: http.MethodPost, header: http.Header{"Idempotency-Key": []string{"ohana"}}, err: timeoutErr, expected: true},
+		{name: "put timeout", method: http.MethodPut, err: timeoutErr, expected: true},
+		{name: "delete timeout", method: http.MethodDelete, err: timeoutErr, expected: true},
+		{name: "options timeout", method: http.MethodOptions, err: timeoutErr, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			req, err := http.NewRequest(method, "https://dummy.com", nil)
+			c.NoError(err)
+
+			if test.header != nil {
+				req.Header = test.header
+			}
+
+			c.Equal(test.expected, DefaultRetryPolicy(req, test.resp, test.err))
+		})
+	}
+}
+
+func TestClient_DoRequestWithRetriesNotIdempotent(t *testing.T) {
+	c := require.New(t)
+
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries: 2,
+		Timeout: 20 * time.Millisecond,
+		Backoff: ConstantBackoff(time.Millisecond),
+	})
+
+	// A POST that timed out may have been applied already, so it's not sent again
+	response, err := client.PostWithURLJSONParams(server.URL, map[string]string{"method": "eth_sendRawTransaction"}, http.Header{})
+	c.Error(err)
+	c.Nil(response)
+	c.Equal(int32(1), calls.Load())
+
+	// Unless it has an idempotency key
+	calls.Store(0)
+
+	response, err = client.PostWithURLJSONParams(server.URL, map[string]string{"method": "eth_sendRawTransaction"},
+		http.Header{"Idempotency-Key": []string{"ohana"}})
+	c.Error(err)
+	c.Nil(response)
+	c.Equal(int32(3), calls.Load())
+
+	calls.Store(0)
+
+	response, err = client.GetWithURLAndParams(server.URL, nil, http.Header{})
+	c.Error(err)
+	c.Nil(response)
+	c.Equal(int32(3), calls.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		value         string
+		expectedDelay time.Duration
+		expectedOk    bool
+	}{
+		{name: "empty", value: "", expectedOk: false},
+		{name: "seconds", value: "3", expectedDelay: 3 * time.Second, expectedOk: true},
+		{name: "negative seconds", value: "-3", expectedOk: false},
+		{name: "http date", value: "Sun, 01 Jan 2023 00:00:10 GMT", expectedDelay: 10 * time.Second, expectedOk: true},
+		{name: "http date in the past", value: "Sat, 31 Dec 2022 23:59:00 GMT", expectedDelay: 0, expectedOk: true},
+		{name: "invalid", value: "tomorrow", expectedOk: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delay, ok := parseRetryAfter(test.value, now)
+			c.Equal(test.expectedOk, ok)
+			c.Equal(test.expectedDelay, delay)
+		})
+	}
+}
+
+func TestClient_DoRequestWithRetriesRetryAfter(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries: 1,
+		Timeout: time.Second,
+		Backoff: ConstantBackoff(time.Millisecond),
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	calls := 0
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com", func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			resp := httpmock.NewStringResponse(http.StatusTooManyRequests, `{"error": "slow down"}`)
+			resp.Header.Set("Retry-After", "1")
+			return resp, nil
+		}
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"ok": 1}`), nil
+	})
+
+	start := time.Now()
+
+	response, err := client.GetWithURLAndParams("https://dummy.com", url.Values{}, http.Header{})
+	c.NoError(err)
+	c.Equal(http.StatusOK, response.StatusCode)
+	c.NoError(response.Body.Close())
+	c.GreaterOrEqual(time.Since(start), time.Second)
+	c.Equal(2, calls)
+}
+
+func TestClient_DoRequestWithRetriesMaxRetryAfter(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries:       1,
+		Timeout:       time.Second,
+		Backoff:       ConstantBackoff(time.Millisecond),
+		MaxRetryAfter: time.Second,
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com", func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusServiceUnavailable, `{"error": "maintenance"}`)
+		resp.Header.Set("Retry-After", "3600")
+		return resp, nil
+	})
+
+	response, err := client.GetWithURLAndParams("https://dummy.com", url.Values{}, http.Header{})
+	c.NoError(err)
+	c.Equal(http.StatusServiceUnavailable, response.StatusCode)
+	c.NoError(response.Body.Close())
+	c.Equal(1, httpmock.GetTotalCallCount())
+}
+
+func TestClient_DoRequestWithRetriesNetworkError(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries: 2,
+		Timeout: time.Second,
+		Backoff: ConstantBackoff(time.Millisecond),
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	calls := 0
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com", func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls < 3 {
+			return nil, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+		}
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"ok": 1}`), nil
+	})
+
+	response, err := client.GetWithURLAndParams("https://dummy.com", url.Values{}, http.Header{})
+	c.NoError(err)
+	c.Equal(http.StatusOK, response.StatusCode)
+	c.NoError(response.Body.Close())
+	c.Equal(3, calls)
+
+	client = NewCustomClientWithOptions(CustomClientOpts{
+		Retries: 2,
+		Timeout: time.Second,
+		Backoff: ConstantBackoff(time.Millisecond),
+		RetryPolicy: func(req *http.Request, resp *http.Response, err error) bool {
+			return false
+		},
+	})
+
+	calls = 0
+
+	response, err = client.GetWithURLAndParams("https://dummy.com", url.Values{}, http.Header{})
+	c.Error(err)
+	c.Nil(response)
+	c.Equal(1, calls)
+}
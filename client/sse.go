@@ -0,0 +1,284 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	contentTypeEventStream = "text/event-stream"
+	lastEventIDHeader      = "Last-Event-ID"
+	defaultEventType       = "message"
+	defaultReconnectDelay  = 3 * time.Second
+)
+
+// Event is a server-sent event, see https://html.spec.whatwg.org/multipage/server-sent-events.html
+type Event struct {
+	// ID is the last event ID set by the server, sent back on the Last-Event-ID header when reconnecting
+	ID string
+	// Type defaults to message
+	Type string
+	Data string
+}
+
+// StreamOpts are the options of an event stream
+type StreamOpts struct {
+	// ReconnectDelay is the wait before reconnecting once the connection is lost, defaults to 3 seconds
+	// the server can change it with the retry field
+	ReconnectDelay time.Duration
+	// MaxReconnects is the max amount of reconnections in a row without receiving events,
+	// zero means no limit and a negative value disables reconnecting
+	MaxReconnects int
+}
+
+// EventStream reads the server-sent events of a response event by event, reconnecting
+// when the connection is lost
+//
+//	for stream.Next() {
+//		event := stream.Event()
+//	}
+//	if err := stream.Err(); err != nil {
+//	}
+type EventStream struct {
+	streamBody
+	client     *Client
+	req        *http.Request
+	opts       StreamOpts
+	parser     eventParser
+	scanner    *bufio.Scanner
+	event      Event
+	reconnects int
+	err        error
+}
+
+// StreamEvents sends the request with the client retry policy and returns a stream of its server-sent events,
+// the stream must be closed once done. Once the connection is lost the request is sent again with the
+// Last-Event-ID header, unless the server responded with 204 No Content or a non 2xx status
+func (c *Client) StreamEvents(req *http.Request, opts StreamOpts) (*EventStream, error) {
+	if err := BufferRequestBody(req); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+
+	req = req.Clone(ctx)
+	req.Header.Set("Accept", contentTypeEventStream)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	stream := &EventStream{
+		streamBody: streamBody{ctx: ctx, cancel: cancel},
+		client:     c,
+		req:        req,
+		opts:       opts,
+		parser:     eventParser{retry: valueOrDefault(opts.ReconnectDelay, defaultReconnectDelay)},
+	}
+
+	if err := stream.connect(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+// Next reads the next event, returning false once the stream ends, fails or is out of reconnections
+func (s *EventStream) Next() bool {
+	for s.err == nil && s.scanner != nil {
+		if s.readEvent() {
+			s.reconnects = 0
+			return true
+		}
+
+		s.reconnect()
+	}
+
+	return false
+}
+
+// Event returns the event read by the last call to Next
+func (s *EventStream) Event() Event {
+	return s.event
+}
+
+// Err returns the error the stream failed with, nil if it ended normally or was closed
+func (s *EventStream) Err() error {
+	return s.err
+}
+
+// LastEventID returns the last event ID set by the server
+func (s *EventStream) LastEventID() string {
+	return s.parser.lastEventID
+}
+
+func (s *EventStream) readEvent() bool {
+	for s.scanner.Scan() {
+		if event, ok := s.parser.line(s.scanner.Text()); ok {
+			s.event = event
+			return true
+		}
+	}
+
+	return false
+}
+
+// reconnect opens the stream again after the reconnect delay, the scanner is left nil if it can't
+func (s *EventStream) reconnect() {
+	readErr := s.scanner.Err()
+	s.scanner = nil
+	s.closeBody()
+
+	if s.closed.Load() || s.ctx.Err() != nil {
+		s.err = s.endErr(nil)
+		return
+	}
+
+	if errors.Is(readErr, bufio.ErrTooLong) || s.outOfReconnects() {
+		s.err = readErr
+		return
+	}
+
+	s.reconnects++
+
+	if err := sleepWithContext(s.ctx, s.parser.retry); err != nil {
+		s.err = s.endErr(err)
+		return
+	}
+
+	s.err = s.endErr(s.connect())
+}
+
+func (s *EventStream) outOfReconnects() bool {
+	return s.opts.MaxReconnects < 0 || s.opts.MaxReconnects > 0 && s.reconnects >= s.opts.MaxReconnects
+}
+
+// connect sends the request, leaving the scanner nil if the server asked to not reconnect with a 204
+func (s *EventStream) connect() error {
+	req := s.req.Clone(s.ctx)
+	if s.req.GetBody != nil {
+		body, err := s.req.GetBody()
+		if err != nil {
+			return err
+		}
+
+		req.Body = body
+	}
+
+	if s.parser.lastEventID != "" {
+		req.Header.Set(lastEventIDHeader, s.parser.lastEventID)
+	}
+
+	resp, err := s.client.openStream(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusNoContent {
+		drainAndClose(resp.Body)
+		return nil
+	}
+
+	s.parser.reset()
+	s.setBody(resp.Body)
+	s.scanner = newLineScanner(resp.Body, s.client.maxResponseSize, scanEventLines)
+
+	return nil
+}
+
+// eventParser builds the events from the stream lines
+type eventParser struct {
+	lastEventID string
+	retry       time.Duration
+	eventType   string
+	data        []string
+}
+
+// line parses the line, returning the event once a blank line ends it
+func (p *eventParser) line(line string) (Event, bool) {
+	if line == "" {
+		return p.dispatch()
+	}
+
+	// Lines starting with a colon are comments
+	if strings.HasPrefix(line, ":") {
+		return Event{}, false
+	}
+
+	name, value, _ := strings.Cut(line, ":")
+	p.field(name, strings.TrimPrefix(value, " "))
+
+	return Event{}, false
+}
+
+func (p *eventParser) field(name, value string) {
+	switch name {
+	case "event":
+		p.eventType = value
+	case "data":
+		p.data = append(p.data, value)
+	case "id":
+		if !strings.ContainsRune(value, 0) {
+			p.lastEventID = value
+		}
+	case "retry":
+		if milliseconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+			p.retry = time.Duration(milliseconds) * time.Millisecond
+		}
+	}
+}
+
+// dispatch returns the event built so far, events without data are dropped
+func (p *eventParser) dispatch() (Event, bool) {
+	defer p.reset()
+
+	if p.data == nil {
+		return Event{}, false
+	}
+
+	event := Event{
+		ID:   p.lastEventID,
+		Type: p.eventType,
+		Data: strings.Join(p.data, "\n"),
+	}
+
+	if event.Type == "" {
+		event.Type = defaultEventType
+	}
+
+	return event, true
+}
+
+// reset drops the event being built, the last event ID and retry are kept
+func (p *eventParser) reset() {
+	p.eventType = ""
+	p.data = nil
+}
+
+// scanEventLines splits the stream in lines ending in \r\n, \n or \r
+func scanEventLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	i := bytes.IndexAny(data, "\r\n")
+	switch {
+	case i < 0 && atEOF:
+		return len(data), data, nil
+	case i < 0:
+		return 0, nil, nil
+	case data[i] == '\n':
+		return i + 1, data[:i], nil
+	case i+1 < len(data) && data[i+1] == '\n':
+		return i + 2, data[:i], nil
+	case i+1 < len(data) || atEOF:
+		return i + 1, data[:i], nil
+	default:
+		// A \r at the end of the data could be followed by a \n
+		return 0, nil, nil
+	}
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_StreamEvents(t *testing.T) {
+	c := require.New(t)
+
+	var connections atomic.Int32
+
+	var lastEventIDs []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connection := connections.Add(1)
+		lastEventIDs = append(lastEventIDs, r.Header.Get(lastEventIDHeader))
+
+		switch connection {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			c.Equal(contentTypeEventStream, r.Header.Get("Accept"))
+			w.Header().Set("Content-Type", contentTypeEventStream)
+			fmt.Fprint(w, "retry: 10\nid: 1\ndata: first\n\n: comment\nevent: update\ndata: a\ndata:b\n\n")
+			// Events not ended by a blank line are dropped
+			fmt.Fprint(w, "data: dropped\n")
+		case 3:
+			w.Header().Set("Content-Type", contentTypeEventStream)
+			fmt.Fprint(w, "id: 2\r\ndata: second\r\n\r\nid\rdata: third\r\r")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := NewCustomClientWithOptions(CustomClientOpts{Retries: 1, Backoff: ConstantBackoff(0)})
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	c.NoError(err)
+
+	stream, err := client.StreamEvents(req, StreamOpts{})
+	c.NoError(err)
+	defer stream.Close()
+
+	var events []Event
+	for stream.Next() {
+		events = append(events, stream.Event())
+	}
+
+	c.NoError(stream.Err())
+	c.Equal([]Event{
+		{ID: "1", Type: "message", Data: "first"},
+		{ID: "1", Type: "update", Data: "a\nb"},
+		{ID: "2", Type: "message", Data: "second"},
+		{ID: "", Type: "message", Data: "third"},
+	}, events)
+
+	// The first connection was retried and the reconnections sent the last event ID
+	c.Equal(int32(4), connections.Load())
+	c.Equal([]string{"", "", "1", ""}, lastEventIDs)
+}
+
+func TestClient_StreamEventsClose(t *testing.T) {
+	c := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentTypeEventStream)
+
+		for i := 1; ; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: tick\n\n", i)
+			w.(http.Flusher).Flush()
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	// The client timeout doesn't apply to streams
+	client := NewCustomClientWithOptions(CustomClientOpts{Timeout: 50 * time.Millisecond, AttemptTimeout: 50 * time.Millisecond})
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	c.NoError(err)
+
+	stream, err := client.StreamEvents(req, StreamOpts{})
+	c.NoError(err)
+
+	for i := 0; i < 3; i++ {
+		c.True(stream.Next())
+	}
+
+	c.Equal("3", stream.LastEventID())
+
+	time.AfterFunc(20*time.Millisecond, func() {
+		c.NoError(stream.Close())
+	})
+
+	c.False(stream.Next())
+	c.NoError(stream.Err())
+	c.NoError(stream.Close())
+}
+
+func TestClient_StreamEventsReconnects(t *testing.T) {
+	c := require.New(t)
+
+	var connections atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if connections.Add(1) > 2 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		fmt.Fprint(w, ": no events\n\n")
+	}))
+	defer server.Close()
+
+	client := NewDefaultClient()
+
+	tests := []struct {
+		name            string
+		opts            StreamOpts
+		wantConnections int32
+		wantStatus      int
+	}{
+		{name: "reconnect disabled", opts: StreamOpts{MaxReconnects: -1}, wantConnections: 1},
+		{name: "max reconnects", opts: StreamOpts{ReconnectDelay: time.Millisecond, MaxReconnects: 1}, wantConnections: 2},
+		{name: "failed reconnection", opts: StreamOpts{ReconnectDelay: time.Millisecond}, wantConnections: 3, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		connections.Store(0)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		c.NoError(err)
+
+		stream, err := client.StreamEvents(req, tt.opts)
+		c.NoError(err, tt.name)
+
+		c.False(stream.Next(), tt.name)
+		c.Equal(tt.wantConnections, connections.Load(), tt.name)
+
+		if tt.wantStatus != 0 {
+			var httpErr *HTTPError
+			c.ErrorAs(stream.Err(), &httpErr, tt.name)
+			c.Equal(tt.wantStatus, httpErr.StatusCode, tt.name)
+		} else {
+			c.NoError(stream.Err(), tt.name)
+		}
+
+		c.NoError(stream.Close())
+	}
+
+	// Failing to connect returns the error right away
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	c.NoError(err)
+
+	_, err = client.StreamEvents(req, StreamOpts{})
+	c.ErrorContains(err, "unexpected status code 404")
+}
+
+func TestClient_StreamEventsContextCancelled(t *testing.T) {
+	c := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader(`{"subscribe": "blocks"}`))
+	c.NoError(err)
+
+	stream, err := NewDefaultClient().StreamEvents(req, StreamOpts{})
+	c.NoError(err)
+	defer stream.Close()
+
+	c.True(stream.Next())
+	c.Equal("first", stream.Event().Data)
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	c.False(stream.Next())
+	c.ErrorIs(stream.Err(), context.Canceled)
+}
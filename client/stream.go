@@ -0,0 +1,209 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const contentTypeNDJSON = "application/x-ndjson"
+
+type streamingKey struct{}
+
+// isStreaming checks if the request is opening a stream, streams are not bound by the client timeouts
+func isStreaming(ctx context.Context) bool {
+	streaming, _ := ctx.Value(streamingKey{}).(bool)
+	return streaming
+}
+
+// httpClient returns the HTTP client to send the request with, without timeout for streams
+func (c *Client) httpClient(req *http.Request) *http.Client {
+	if !isStreaming(req.Context()) || c.Client.Timeout == 0 {
+		return c.Client
+	}
+
+	client := *c.Client
+	client.Timeout = 0
+
+	return &client
+}
+
+// openStream sends the request with the client retry policy, failing with an HTTPError on non 2xx responses
+// the stream lasts until its body is closed or its context is done, regardless of the client timeouts
+func (c *Client) openStream(req *http.Request) (*http.Response, error) {
+	req = c.withDefaults(req)
+	req = req.WithContext(context.WithValue(req.Context(), streamingKey{}, true))
+
+	resp, _, err := c.sendWithRetries(req, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		defer drainAndClose(resp.Body)
+		return nil, newHTTPError(resp)
+	}
+
+	return resp, nil
+}
+
+// streamBody is the body of a stream that can be closed while it's being read
+type streamBody struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	body   io.ReadCloser
+	closed atomic.Bool
+}
+
+// Close stops the stream, it can be called while Next is blocked
+func (s *streamBody) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed.Swap(true) {
+		return nil
+	}
+
+	s.cancel()
+
+	if s.body == nil {
+		return nil
+	}
+
+	return s.body.Close()
+}
+
+// setBody sets the body being read, it is closed right away if the stream was closed
+func (s *streamBody) setBody(body io.ReadCloser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed.Load() {
+		_ = body.Close()
+		return
+	}
+
+	s.body = body
+}
+
+// closeBody closes the body being read, to read another one
+func (s *streamBody) closeBody() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.body != nil {
+		_ = s.body.Close()
+		s.body = nil
+	}
+}
+
+// endErr returns the error the stream ended with, nil if it was closed
+func (s *streamBody) endErr(err error) error {
+	if s.closed.Load() {
+		return nil
+	}
+
+	if ctxErr := s.ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	return err
+}
+
+// newLineScanner returns a scanner of the body lines up to maxSize long
+func newLineScanner(body io.Reader, maxSize int64, split bufio.SplitFunc) *bufio.Scanner {
+	if maxSize < 1 {
+		maxSize = defaultMaxResponseSize
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), int(maxSize))
+	scanner.Split(split)
+
+	return scanner
+}
+
+// JSONStream reads a newline delimited JSON stream value by value
+//
+//	for stream.Next() {
+//		value := stream.Value()
+//	}
+//	if err := stream.Err(); err != nil {
+//	}
+type JSONStream[T any] struct {
+	streamBody
+	scanner *bufio.Scanner
+	value   T
+	err     error
+}
+
+// StreamJSON sends the request with the client retry policy and returns a stream of the newline delimited
+// JSON values of the response, the stream must be closed once done. Lines longer than the client
+// MaxResponseSize make the stream fail
+func StreamJSON[T any](c *Client, req *http.Request) (*JSONStream[T], error) {
+	ctx, cancel := context.WithCancel(req.Context())
+
+	req = req.Clone(ctx)
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", contentTypeNDJSON)
+	}
+
+	resp, err := c.openStream(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	stream := &JSONStream[T]{
+		streamBody: streamBody{ctx: ctx, cancel: cancel},
+		scanner:    newLineScanner(resp.Body, c.maxResponseSize, bufio.ScanLines),
+	}
+	stream.setBody(resp.Body)
+
+	return stream, nil
+}
+
+// Next reads the next value, returning false once the stream ends or fails
+func (s *JSONStream[T]) Next() bool {
+	if s.err != nil {
+		return false
+	}
+
+	for s.scanner.Scan() {
+		line := bytes.TrimSpace(s.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var value T
+		if err := json.Unmarshal(line, &value); err != nil {
+			s.err = err
+			return false
+		}
+
+		s.value = value
+
+		return true
+	}
+
+	s.err = s.endErr(s.scanner.Err())
+
+	return false
+}
+
+// Value returns the value read by the last call to Next
+func (s *JSONStream[T]) Value() T {
+	return s.value
+}
+
+// Err returns the error the stream failed with, nil if it ended normally or was closed
+func (s *JSONStream[T]) Err() error {
+	return s.err
+}
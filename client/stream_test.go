@@ -0,0 +1,91 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamJSON(t *testing.T) {
+	c := require.New(t)
+
+	type block struct {
+		Number int `json:"number"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Equal(contentTypeNDJSON, r.Header.Get("Accept"))
+
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, "{\"number\": %d}\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		fmt.Fprint(w, "{\"number\": \n")
+	}))
+	defer server.Close()
+
+	client := NewCustomClientWithOptions(CustomClientOpts{Timeout: 10 * time.Millisecond})
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	c.NoError(err)
+
+	stream, err := StreamJSON[block](client, req)
+	c.NoError(err)
+	defer stream.Close()
+
+	var blocks []block
+	for stream.Next() {
+		blocks = append(blocks, stream.Value())
+	}
+
+	c.Equal([]block{{Number: 1}, {Number: 2}, {Number: 3}}, blocks)
+
+	var syntaxErr *json.SyntaxError
+	c.ErrorAs(stream.Err(), &syntaxErr)
+	c.False(stream.Next())
+}
+
+func TestStreamJSONClose(t *testing.T) {
+	c := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{\"number\": 1}\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	c.NoError(err)
+
+	stream, err := StreamJSON[map[string]int](NewDefaultClient(), req)
+	c.NoError(err)
+
+	c.True(stream.Next())
+	c.Equal(map[string]int{"number": 1}, stream.Value())
+
+	time.AfterFunc(20*time.Millisecond, func() {
+		c.NoError(stream.Close())
+	})
+
+	c.False(stream.Next())
+	c.NoError(stream.Err())
+
+	// Non 2xx responses fail the stream right away
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	req, err = http.NewRequest(http.MethodGet, server.URL, nil)
+	c.NoError(err)
+
+	_, err = StreamJSON[map[string]int](NewDefaultClient(), req)
+	c.ErrorContains(err, "unexpected status code 400")
+}
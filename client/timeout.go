@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// withTotalTimeout returns the request with the client total deadline set on its context
+// the deadline is zero and the cancel func nil if the client has no total timeout
+func (c *Client) withTotalTimeout(req *http.Request) (*http.Request, time.Time, context.CancelFunc) {
+	if c.totalTimeout <= 0 {
+		return req, time.Time{}, nil
+	}
+
+	deadline := time.Now().Add(c.totalTimeout)
+	ctx, cancel := context.WithDeadline(req.Context(), deadline)
+
+	return req.WithContext(ctx), deadline, cancel
+}
+
+// sendWithTimeout sends the attempt with the client per attempt timeout set on its context
+// streams are not bound by it, as they last until closed
+func (c *Client) sendWithTimeout(req *http.Request) (*http.Response, error) {
+	if c.attemptTimeout <= 0 || isStreaming(req.Context()) {
+		return c.sendTraced(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), c.attemptTimeout)
+
+	resp, err := c.sendTraced(req.WithContext(ctx))
+
+	return withCancelOnClose(resp, cancel), err
+}
+
+// fitsAnotherAttempt checks if there's time left before the total deadline to wait the delay
+// and make another attempt, which is expected to last the whole per attempt timeout
+// a zero deadline means the client has no total timeout
+func (c *Client) fitsAnotherAttempt(deadline time.Time, delay time.Duration) bool {
+	if deadline.IsZero() {
+		return true
+	}
+
+	return time.Until(deadline) > delay+c.attemptTimeout
+}
+
+// withCancelOnClose makes the response cancel its context once its body is closed
+// so it can still be read after the request returns, the context is cancelled right away without response
+func withCancelOnClose(resp *http.Response, cancel context.CancelFunc) *http.Response {
+	if cancel == nil {
+		return resp
+	}
+
+	if resp == nil {
+		cancel()
+		return nil
+	}
+
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp
+}
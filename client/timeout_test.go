@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AttemptTimeout(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries:        1,
+		Backoff:        ConstantBackoff(0),
+		AttemptTimeout: 50 * time.Millisecond,
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var calls atomic.Int32
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/block", func(req *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"block": 10}`), nil
+	})
+
+	response, err := client.GetWithURLAndParams("https://dummy.com/block", nil, nil)
+	c.NoError(err)
+	c.Equal(int32(2), calls.Load())
+
+	// The body can be read after the request returns
+	body, err := io.ReadAll(response.Body)
+	c.NoError(err)
+	c.Equal(`{"block": 10}`, string(body))
+	c.NoError(response.Body.Close())
+}
+
+func TestClient_TotalTimeout(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	tests := []struct {
+		name           string
+		opts           CustomClientOpts
+		wantAttempts   int32
+		wantMaxElapsed time.Duration
+	}{
+		{
+			name: "retries until the wait doesn't fit",
+			opts: CustomClientOpts{
+				Retries:      10,
+				Backoff:      ConstantBackoff(100 * time.Millisecond),
+				TotalTimeout: 250 * time.Millisecond,
+			},
+			wantAttempts:   3,
+			wantMaxElapsed: 250 * time.Millisecond,
+		},
+		{
+			name: "budgets the per attempt timeout",
+			opts: CustomClientOpts{
+				Retries:        10,
+				Backoff:        ConstantBackoff(300 * time.Millisecond),
+				AttemptTimeout: 500 * time.Millisecond,
+				TotalTimeout:   time.Second,
+			},
+			wantAttempts:   2,
+			wantMaxElapsed: 500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		var calls atomic.Int32
+
+		httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/block", func(*http.Request) (*http.Response, error) {
+			calls.Add(1)
+			return httpmock.NewStringResponse(http.StatusServiceUnavailable, `{"error": "unavailable"}`), nil
+		})
+
+		start := time.Now()
+
+		// The last response is returned once there's no time for another attempt
+		response, err := NewCustomClientWithOptions(tt.opts).GetWithURLAndParams("https://dummy.com/block", nil, nil)
+		c.NoError(err, tt.name)
+		c.Equal(http.StatusServiceUnavailable, response.StatusCode, tt.name)
+		c.NoError(response.Body.Close())
+
+		c.Equal(tt.wantAttempts, calls.Load(), tt.name)
+		c.Less(time.Since(start), tt.wantMaxElapsed, tt.name)
+	}
+}
+
+func TestClient_TotalTimeoutExceeded(t *testing.T) {
+	c := require.New(t)
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries:      3,
+		Backoff:      ConstantBackoff(0),
+		TotalTimeout: 50 * time.Millisecond,
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/block", func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+
+	_, err := client.GetWithURLAndParams("https://dummy.com/block", nil, nil)
+	c.ErrorIs(err, context.DeadlineExceeded)
+}
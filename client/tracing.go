@@ -0,0 +1,232 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Header names of the W3C trace context, see https://www.w3.org/TR/trace-context
+const (
+	TraceParentHeader = "Traceparent"
+	TraceStateHeader  = "Tracestate"
+)
+
+// Phases of an attempt reported to AttemptSpan.Phase
+const (
+	PhaseDNS       = "dns"
+	PhaseConnect   = "connect"
+	PhaseTLS       = "tls"
+	PhaseFirstByte = "first_byte"
+)
+
+const (
+	traceParentLength  = 55
+	traceParentVersion = "00"
+)
+
+// ErrInvalidTraceParent is returned when a traceparent header can't be parsed
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+type traceContextKey struct{}
+
+// TraceContext is the W3C trace context propagated on the traceparent and tracestate headers
+type TraceContext struct {
+	TraceID [16]byte
+	// SpanID is the ID of the span the request is sent from, the parent of the server span
+	SpanID     [8]byte
+	Flags      byte
+	TraceState string
+}
+
+// IsValid checks if the trace and span IDs are set
+func (t TraceContext) IsValid() bool {
+	return t.TraceID != [16]byte{} && t.SpanID != [8]byte{}
+}
+
+// Sampled checks if the sampled flag is set
+func (t TraceContext) Sampled() bool {
+	return t.Flags&1 == 1
+}
+
+// TraceParent returns the value of the traceparent header
+func (t TraceContext) TraceParent() string {
+	return traceParentVersion + "-" + hex.EncodeToString(t.TraceID[:]) + "-" +
+		hex.EncodeToString(t.SpanID[:]) + "-" + hex.EncodeToString([]byte{t.Flags})
+}
+
+// ParseTraceParent parses the value of a traceparent header, the trace state is left empty
+func ParseTraceParent(value string) (TraceContext, error) {
+	var traceContext TraceContext
+
+	parts := strings.Split(value, "-")
+	if len(value) < traceParentLength || len(parts) < 4 {
+		return traceContext, ErrInvalidTraceParent
+	}
+
+	// Newer versions can add fields at the end, version 00 can't
+	if parts[0] == "ff" || parts[0] == traceParentVersion && len(parts) != 4 {
+		return traceContext, ErrInvalidTraceParent
+	}
+
+	var version, flags [1]byte
+	for _, field := range []struct {
+		dst   []byte
+		value string
+	}{
+		{version[:], parts[0]},
+		{traceContext.TraceID[:], parts[1]},
+		{traceContext.SpanID[:], parts[2]},
+		{flags[:], parts[3]},
+	} {
+		if !decodeHexField(field.dst, field.value) {
+			return traceContext, ErrInvalidTraceParent
+		}
+	}
+
+	traceContext.Flags = flags[0]
+	if !traceContext.IsValid() {
+		return traceContext, ErrInvalidTraceParent
+	}
+
+	return traceContext, nil
+}
+
+// decodeHexField decodes the lowercase hex value filling the whole dst
+func decodeHexField(dst []byte, value string) bool {
+	if len(value) != hex.EncodedLen(len(dst)) || strings.ToLower(value) != value {
+		return false
+	}
+
+	_, err := hex.Decode(dst, []byte(value))
+
+	return err == nil
+}
+
+// ContextWithTraceContext returns a context with the trace context to propagate on the requests sent with it
+func ContextWithTraceContext(ctx context.Context, traceContext TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, traceContext)
+}
+
+// TraceContextFromContext returns the trace context set with ContextWithTraceContext
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	traceContext, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return traceContext, ok && traceContext.IsValid()
+}
+
+// Tracer starts a span for every attempt sent by DoRequestWithRetries, it can be adapted to any tracing SDK
+type Tracer interface {
+	// StartAttempt is called right before sending the attempt, the attempt is sent with the returned context
+	// so a trace context set on it with ContextWithTraceContext is propagated instead of the caller one
+	StartAttempt(ctx context.Context, req *http.Request, attempt int) (context.Context, AttemptSpan)
+}
+
+// AttemptSpan receives the timings of an attempt
+type AttemptSpan interface {
+	// Phase is called when a phase of the attempt is done, the phases are PhaseDNS, PhaseConnect,
+	// PhaseTLS and PhaseFirstByte, the last one going from the request written to the first response byte
+	// phases are skipped when a connection is reused
+	Phase(name string, start, end time.Time, err error)
+	// End is called once the response headers are received or the attempt failed
+	End(resp *http.Response, err error)
+}
+
+// traceAttempt starts the tracer span and adds the trace context headers to the request
+// the span is nil if the client has no tracer
+func (c *Client) traceAttempt(req *http.Request) (*http.Request, AttemptSpan) {
+	ctx := req.Context()
+
+	var span AttemptSpan
+	if c.tracer != nil {
+		ctx, span = c.tracer.StartAttempt(ctx, req, AttemptFromContext(ctx))
+		ctx = httptrace.WithClientTrace(ctx, newPhaseTrace(span))
+	}
+
+	traceContext, ok := TraceContextFromContext(ctx)
+	if !ok {
+		if span == nil {
+			return req, nil
+		}
+
+		return req.WithContext(ctx), span
+	}
+
+	req = req.WithContext(ctx)
+	if req.Header.Get(TraceParentHeader) != "" {
+		return req, span
+	}
+
+	req = setHeader(req, TraceParentHeader, traceContext.TraceParent())
+	if traceContext.TraceState != "" {
+		req.Header.Set(TraceStateHeader, traceContext.TraceState)
+	}
+
+	return req, span
+}
+
+// sendTraced sends the attempt with the tracing set
+func (c *Client) sendTraced(req *http.Request) (*http.Response, error) {
+	req, span := c.traceAttempt(req)
+
+	resp, err := c.send(req)
+	if span != nil {
+		span.End(resp, err)
+	}
+
+	return resp, err
+}
+
+// phaseTrace reports the httptrace phases to the span, the hooks can be called from several goroutines
+type phaseTrace struct {
+	span   AttemptSpan
+	mu     sync.Mutex
+	starts map[string]time.Time
+}
+
+func newPhaseTrace(span AttemptSpan) *httptrace.ClientTrace {
+	trace := &phaseTrace{span: span, starts: make(map[string]time.Time)}
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { trace.start(PhaseDNS) },
+		DNSDone:  func(info httptrace.DNSDoneInfo) { trace.end(PhaseDNS, PhaseDNS, info.Err) },
+		ConnectStart: func(_, addr string) {
+			trace.start(PhaseConnect + " " + addr)
+		},
+		ConnectDone: func(_, addr string, err error) {
+			trace.end(PhaseConnect, PhaseConnect+" "+addr, err)
+		},
+		TLSHandshakeStart: func() { trace.start(PhaseTLS) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			trace.end(PhaseTLS, PhaseTLS, err)
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { trace.start(PhaseFirstByte) },
+		GotFirstResponseByte: func() { trace.end(PhaseFirstByte, PhaseFirstByte, nil) },
+	}
+}
+
+func (p *phaseTrace) start(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.starts[key] = time.Now()
+}
+
+// end reports the phase started with the key, if it was started
+func (p *phaseTrace) end(name, key string, err error) {
+	end := time.Now()
+
+	p.mu.Lock()
+	start, ok := p.starts[key]
+	delete(p.starts, key)
+	p.mu.Unlock()
+
+	if ok {
+		p.span.Phase(name, start, end, err)
+	}
+}
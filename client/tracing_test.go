@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+type testTracer struct {
+	mu     sync.Mutex
+	spans  []*testSpan
+	spanID byte
+}
+
+type testSpan struct {
+	attempt int
+	phases  []string
+	status  int
+	ended   bool
+}
+
+func (t *testTracer) StartAttempt(ctx context.Context, _ *http.Request, attempt int) (context.Context, AttemptSpan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := &testSpan{attempt: attempt}
+	t.spans = append(t.spans, span)
+
+	traceContext, _ := TraceContextFromContext(ctx)
+	t.spanID++
+	traceContext.SpanID = [8]byte{7: t.spanID}
+
+	return ContextWithTraceContext(ctx, traceContext), span
+}
+
+func (s *testSpan) Phase(name string, start, end time.Time, _ error) {
+	if !end.Before(start) {
+		s.phases = append(s.phases, name)
+	}
+}
+
+func (s *testSpan) End(resp *http.Response, _ error) {
+	s.ended = true
+	if resp != nil {
+		s.status = resp.StatusCode
+	}
+}
+
+func TestClient_TraceContextPropagation(t *testing.T) {
+	c := require.New(t)
+
+	traceContext, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	c.NoError(err)
+	traceContext.TraceState = "congo=t61rcWkgMzE"
+
+	tracer := &testTracer{}
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Retries: 1,
+		Backoff: ConstantBackoff(0),
+		Tracer:  tracer,
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var traceParents, traceStates []string
+
+	httpmock.RegisterResponder(http.MethodGet, "https://dummy.com/block", func(req *http.Request) (*http.Response, error) {
+		traceParents = append(traceParents, req.Header.Get(TraceParentHeader))
+		traceStates = append(traceStates, req.Header.Get(TraceStateHeader))
+
+		if len(traceParents) == 1 {
+			return httpmock.NewStringResponse(http.StatusBadGateway, `{"error": "bad gateway"}`), nil
+		}
+
+		return httpmock.NewStringResponse(http.StatusOK, `{"block": 10}`), nil
+	})
+
+	ctx := ContextWithTraceContext(context.Background(), traceContext)
+
+	response, err := client.GetWithURLAndParamsWithCtx(ctx, "https://dummy.com/block", nil, nil)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	// Every attempt is sent with the span started by the tracer as parent
+	c.Equal([]string{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000001-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000002-01",
+	}, traceParents)
+	c.Equal([]string{"congo=t61rcWkgMzE", "congo=t61rcWkgMzE"}, traceStates)
+
+	c.Len(tracer.spans, 2)
+	c.Equal(&testSpan{attempt: 1, status: http.StatusBadGateway, ended: true}, tracer.spans[0])
+	c.Equal(&testSpan{attempt: 2, status: http.StatusOK, ended: true}, tracer.spans[1])
+
+	// Without tracer the caller trace context is propagated as is
+	client = NewDefaultClient()
+
+	response, err = client.GetWithURLAndParamsWithCtx(ctx, "https://dummy.com/block", nil, nil)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+	c.Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceParents[2])
+
+	// A traceparent set by the caller is kept
+	response, err = client.GetWithURLAndParamsWithCtx(ctx, "https://dummy.com/block", nil, http.Header{
+		TraceParentHeader: []string{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00"},
+	})
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+	c.Equal("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00", traceParents[3])
+}
+
+func TestClient_TracePhases(t *testing.T) {
+	c := require.New(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tracer := &testTracer{}
+
+	client := NewCustomClientWithOptions(CustomClientOpts{
+		Transport: server.Client().Transport,
+		Tracer:    tracer,
+	})
+
+	response, err := client.GetWithURLAndParams(server.URL, nil, nil)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	c.Len(tracer.spans, 1)
+	c.Equal([]string{PhaseConnect, PhaseTLS, PhaseFirstByte}, tracer.spans[0].phases)
+}
+
+func TestParseTraceParent(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "valid", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "future version with more fields", value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "version 00 with more fields", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantErr: true},
+		{name: "forbidden version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "zero trace ID", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		{name: "zero span ID", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", wantErr: true},
+		{name: "uppercase", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "short", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		traceContext, err := ParseTraceParent(tt.value)
+		if tt.wantErr {
+			c.ErrorIs(err, ErrInvalidTraceParent, tt.name)
+			continue
+		}
+
+		c.NoError(err, tt.name)
+		c.True(traceContext.Sampled(), tt.name)
+		// Trace parents are always written with the version 00
+		c.Equal("00"+tt.value[2:traceParentLength], traceContext.TraceParent(), tt.name)
+	}
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Defaults of the transport options, tuned for sending many requests to a few hosts
+const (
+	defaultMaxIdleConns          = 1024
+	defaultMaxIdleConnsPerHost   = 256
+	defaultIdleConnTimeout       = 90 * time.Second
+	defaultDialTimeout           = 5 * time.Second
+	defaultKeepAlive             = 30 * time.Second
+	defaultTLSHandshakeTimeout   = 5 * time.Second
+	defaultExpectContinueTimeout = time.Second
+	defaultTLSMinVersion         = tls.VersionTLS12
+)
+
+// ErrInvalidCACert is returned when a CA certificate can't be parsed
+var ErrInvalidCACert = errors.New("invalid CA certificate")
+
+// TransportOpts are the options of the transport built by NewTransport, zero values use the defaults
+type TransportOpts struct {
+	// MaxIdleConns is the max amount of idle connections across all hosts, defaults to 1024
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the max amount of idle connections kept per host, defaults to 256
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the connections per host, including the ones in use, zero means no limit
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept, defaults to 90 seconds
+	IdleConnTimeout time.Duration
+	// DialTimeout is the max time to open a connection, defaults to 5 seconds
+	DialTimeout time.Duration
+	// KeepAlive is the interval of the TCP keep-alive probes, defaults to 30 seconds, a negative value disables them
+	KeepAlive time.Duration
+	// TLSHandshakeTimeout is the max time of the TLS handshake, defaults to 5 seconds
+	TLSHandshakeTimeout time.Duration
+	// ProxyURL is the proxy all the requests are sent through, defaults to the one on the environment variables
+	ProxyURL string
+	// DisableHTTP2 keeps the connections on HTTP/1.1
+	DisableHTTP2 bool
+	TLS          *TLSOpts
+}
+
+// TLSOpts are the TLS options of the transport
+type TLSOpts struct {
+	// RootCAs are PEM encoded CA certificates trusted on top of the system ones
+	RootCAs [][]byte
+	// RootCAFiles are files with PEM encoded CA certificates trusted on top of the system ones
+	RootCAFiles []string
+	// Certificates are the client certificates sent to the servers that ask for them
+	Certificates []tls.Certificate
+	// CertFile and KeyFile are a PEM encoded client certificate and its key, added to Certificates
+	CertFile string
+	KeyFile  string
+	// MinVersion is the min TLS version accepted, defaults to TLS 1.2
+	MinVersion uint16
+	// ServerName overrides the name used to verify the server certificate
+	ServerName string
+	// InsecureSkipVerify disables the verification of the server certificate, only meant for tests
+	InsecureSkipVerify bool
+}
+
+// NewTransport returns an HTTP transport with the given options
+func NewTransport(opts TransportOpts) (*http.Transport, error) {
+	proxy, err := proxyFunc(opts.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfig(opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   valueOrDefault(opts.DialTimeout, defaultDialTimeout),
+		KeepAlive: valueOrDefault(opts.KeepAlive, defaultKeepAlive),
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     !opts.DisableHTTP2,
+		MaxIdleConns:          valueOrDefault(opts.MaxIdleConns, defaultMaxIdleConns),
+		MaxIdleConnsPerHost:   valueOrDefault(opts.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost),
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       valueOrDefault(opts.IdleConnTimeout, defaultIdleConnTimeout),
+		TLSHandshakeTimeout:   valueOrDefault(opts.TLSHandshakeTimeout, defaultTLSHandshakeTimeout),
+		ExpectContinueTimeout: defaultExpectContinueTimeout,
+	}
+
+	if opts.DisableHTTP2 {
+		// A non nil empty map is how the transport is told to not upgrade to HTTP/2
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	return transport, nil
+}
+
+func proxyFunc(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+
+	return http.ProxyURL(parsed), nil
+}
+
+func newTLSConfig(opts *TLSOpts) (*tls.Config, error) {
+	if opts == nil {
+		return &tls.Config{MinVersion: defaultTLSMinVersion}, nil
+	}
+
+	rootCAs, err := certPool(opts.RootCAs, opts.RootCAFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	certificates := append([]tls.Certificate(nil), opts.Certificates...)
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	// #nosec G402 -- skipping the verification is an explicit choice of the caller
+	return &tls.Config{
+		RootCAs:            rootCAs,
+		Certificates:       certificates,
+		MinVersion:         valueOrDefault(opts.MinVersion, defaultTLSMinVersion),
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}, nil
+}
+
+// certPool returns the system certificates plus the given ones, nil if there are no given ones
+// so the system ones are used
+func certPool(certs [][]byte, files []string) (*x509.CertPool, error) {
+	if len(certs) == 0 && len(files) == 0 {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, file := range files {
+		// #nosec G304 -- the file is given by the caller on purpose
+		cert, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if !pool.AppendCertsFromPEM(cert) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCACert, file)
+		}
+	}
+
+	for _, cert := range certs {
+		if !pool.AppendCertsFromPEM(cert) {
+			return nil, ErrInvalidCACert
+		}
+	}
+
+	return pool, nil
+}
+
+func valueOrDefault[T comparable](value, defaultValue T) T {
+	var zero T
+	if value == zero {
+		return defaultValue
+	}
+
+	return value
+}
+
+// clientTransport returns the transport given to the client or, if there's none and it has transport options,
+// a new transport with them. Invalid options make every request fail, as the client constructor can't
+func clientTransport(transport http.RoundTripper, opts *TransportOpts) http.RoundTripper {
+	if transport != nil || opts == nil {
+		return transport
+	}
+
+	tuned, err := NewTransport(*opts)
+	if err != nil {
+		return errorTransport{err: fmt.Errorf("invalid transport options: %w", err)}
+	}
+
+	return tuned
+}
+
+// errorTransport fails every request with the error found while building the transport
+type errorTransport struct {
+	err error
+}
+
+func (t errorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	closeRequestBody(req)
+	return nil, t.err
+}
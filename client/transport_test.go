@@ -0,0 +1,128 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTransport(t *testing.T) {
+	c := require.New(t)
+
+	transport, err := NewTransport(TransportOpts{})
+	c.NoError(err)
+
+	c.Equal(defaultMaxIdleConns, transport.MaxIdleConns)
+	c.Equal(defaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	c.Equal(defaultIdleConnTimeout, transport.IdleConnTimeout)
+	c.Equal(defaultTLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	c.Equal(uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	c.True(transport.ForceAttemptHTTP2)
+	c.Nil(transport.TLSNextProto)
+
+	transport, err = NewTransport(TransportOpts{
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     time.Minute,
+		ProxyURL:            "http://proxy.dummy.com:8080",
+		DisableHTTP2:        true,
+		TLS:                 &TLSOpts{MinVersion: tls.VersionTLS13},
+	})
+	c.NoError(err)
+
+	c.Equal(10, transport.MaxIdleConnsPerHost)
+	c.Equal(time.Minute, transport.IdleConnTimeout)
+	c.Equal(uint16(tls.VersionTLS13), transport.TLSClientConfig.MinVersion)
+	c.False(transport.ForceAttemptHTTP2)
+	c.NotNil(transport.TLSNextProto)
+	c.Empty(transport.TLSNextProto)
+
+	proxy, err := transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "dummy.com"}})
+	c.NoError(err)
+	c.Equal("http://proxy.dummy.com:8080", proxy.String())
+
+	_, err = NewTransport(TransportOpts{ProxyURL: "://bad"})
+	c.Error(err)
+
+	_, err = NewTransport(TransportOpts{TLS: &TLSOpts{RootCAs: [][]byte{[]byte("not a cert")}}})
+	c.ErrorIs(err, ErrInvalidCACert)
+}
+
+func TestClient_TransportOptsTLS(t *testing.T) {
+	c := require.New(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// The server certificate is used as CA and as client certificate
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	certificate := server.TLS.Certificates[0]
+	c.NoError(os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}), 0o600))
+
+	key, err := x509.MarshalPKCS8PrivateKey(certificate.PrivateKey)
+	c.NoError(err)
+	c.NoError(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0o600))
+
+	tests := []struct {
+		name       string
+		tlsOpts    *TLSOpts
+		wantStatus int
+		wantErr    bool
+	}{
+		{
+			name:    "unknown CA",
+			wantErr: true,
+		},
+		{
+			name:       "custom CA",
+			tlsOpts:    &TLSOpts{RootCAFiles: []string{certFile}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "client certificate",
+			tlsOpts:    &TLSOpts{RootCAFiles: []string{certFile}, CertFile: certFile, KeyFile: keyFile},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:    "missing CA file",
+			tlsOpts: &TLSOpts{RootCAFiles: []string{filepath.Join(dir, "missing.pem")}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		client := NewCustomClientWithOptions(CustomClientOpts{
+			TransportOpts: &TransportOpts{TLS: tt.tlsOpts},
+		})
+
+		response, err := client.GetWithURLAndParams(server.URL, nil, nil)
+		if tt.wantErr {
+			c.Error(err, tt.name)
+			continue
+		}
+
+		c.NoError(err, tt.name)
+		c.Equal(tt.wantStatus, response.StatusCode, tt.name)
+		c.NoError(response.Body.Close())
+	}
+}
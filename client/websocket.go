@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/coder/websocket"
+)
+
+// ErrWSHandshake is returned when the server doesn't accept the WebSocket upgrade
+var ErrWSHandshake = errors.New("websocket handshake failed")
+
+// dialWebSocket opens a WebSocket connection to the ws, wss, http or https URL, the handshake is sent
+// with the HTTP client so it goes through its transport and proxy
+// a handshake rejected with a status other than 101 fails with an *HTTPError
+func dialWebSocket(ctx context.Context, rawURL string, header http.Header, httpClient *http.Client) (*websocket.Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "ws", "wss", "http", "https":
+	default:
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrWSHandshake, u.Scheme)
+	}
+
+	conn, resp, err := websocket.Dial(ctx, rawURL, &websocket.DialOptions{
+		HTTPClient: httpClient,
+		HTTPHeader: header,
+	})
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			return nil, fmt.Errorf("%w: %w", ErrWSHandshake, newHTTPError(resp))
+		}
+
+		return nil, err
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestEchoServer returns a WebSocket server on /ws answering every message with the same one
+func newTestEchoServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+
+		conn, err := websocket.Accept(w, r, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.CloseNow()
+
+		messageType, message, err := conn.Read(r.Context())
+		if err != nil {
+			return
+		}
+
+		assert.NoError(t, conn.Write(r.Context(), messageType, message))
+	}))
+}
+
+func TestDialWebSocket(t *testing.T) {
+	c := require.New(t)
+
+	server := newTestEchoServer(t)
+	defer server.Close()
+
+	wsURL := strings.Replace(server.URL, "http://", "ws://", 1)
+
+	conn, err := dialWebSocket(context.Background(), wsURL+"/ws", http.Header{"X-Api-Key": []string{"secret"}}, http.DefaultClient)
+	c.NoError(err)
+	defer conn.CloseNow()
+
+	c.NoError(conn.Write(context.Background(), websocket.MessageText, []byte("ohana")))
+
+	_, message, err := conn.Read(context.Background())
+	c.NoError(err)
+	c.Equal("ohana", string(message))
+
+	_, err = dialWebSocket(context.Background(), wsURL+"/missing", nil, http.DefaultClient)
+	c.ErrorIs(err, ErrWSHandshake)
+
+	var httpErr *HTTPError
+	c.ErrorAs(err, &httpErr)
+	c.Equal(http.StatusNotFound, httpErr.StatusCode)
+	c.Contains(string(httpErr.Body), "not found")
+
+	_, err = dialWebSocket(context.Background(), "ftp://dummy.com", nil, http.DefaultClient)
+	c.ErrorIs(err, ErrWSHandshake)
+}
+
+func TestDialWebSocket_Proxy(t *testing.T) {
+	c := require.New(t)
+
+	server := newTestEchoServer(t)
+	defer server.Close()
+
+	var proxied atomic.Int32
+
+	// The proxy forwards the handshake to the server and then tunnels the connection
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		if r.Write(upstream) != nil {
+			return
+		}
+
+		go func() {
+			_, _ = io.Copy(upstream, conn)
+		}()
+
+		_, _ = io.Copy(conn, upstream)
+	}))
+	defer proxy.Close()
+
+	transport, err := NewTransport(TransportOpts{ProxyURL: proxy.URL})
+	c.NoError(err)
+
+	wsURL := strings.Replace(server.URL, "http://", "ws://", 1)
+
+	conn, err := dialWebSocket(context.Background(), wsURL+"/ws", http.Header{"X-Api-Key": []string{"secret"}},
+		&http.Client{Transport: transport})
+	c.NoError(err)
+	defer conn.CloseNow()
+
+	c.NoError(conn.Write(context.Background(), websocket.MessageText, []byte("ohana")))
+
+	_, message, err := conn.Read(context.Background())
+	c.NoError(err)
+	c.Equal("ohana", string(message))
+	c.Equal(int32(1), proxied.Load())
+}
@@ -0,0 +1,670 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/pokt-foundation/utils-go/logger"
+)
+
+const (
+	defaultWSDialTimeout        = 10 * time.Second
+	defaultWSWriteTimeout       = 10 * time.Second
+	defaultWSPingInterval       = 30 * time.Second
+	defaultWSPongTimeout        = 10 * time.Second
+	defaultWSSubscriptionBuffer = 128
+	defaultWSMinReconnectDelay  = 500 * time.Millisecond
+	defaultWSMaxReconnectDelay  = 30 * time.Second
+)
+
+var (
+	// ErrWSRPCClosed is returned by the calls done on a closed client
+	ErrWSRPCClosed = errors.New("websocket json-rpc client closed")
+	// ErrWSConnectionLost is returned by the calls in flight when the connection is lost
+	ErrWSConnectionLost = errors.New("websocket connection lost")
+	// ErrSubscriptionOverflow ends a subscription whose notifications are not read fast enough
+	ErrSubscriptionOverflow = errors.New("subscription notifications buffer full")
+)
+
+// WSRPCOpts are the options of the WebSocket JSON-RPC client, zero values use the defaults
+type WSRPCOpts struct {
+	// Header is sent on the WebSocket handshake
+	Header http.Header
+	// Transport sends the WebSocket handshake, it must return writable bodies for the upgrade responses
+	// like http.Transport does, defaults to http.DefaultTransport, which uses the proxy on the environment variables
+	Transport http.RoundTripper
+	// TransportOpts builds a transport tuned with them when no Transport is given, see NewTransport
+	TransportOpts *TransportOpts
+	// DialTimeout is the max time to connect, including the handshake, defaults to 10 seconds
+	DialTimeout time.Duration
+	// WriteTimeout is the max time to write a message, defaults to 10 seconds
+	WriteTimeout time.Duration
+	// PingInterval is how often pings are sent to keep the connection alive, defaults to 30 seconds,
+	// a negative value disables them
+	PingInterval time.Duration
+	// PongTimeout is how long to wait for the pong of a ping before reconnecting, defaults to 10 seconds
+	PongTimeout time.Duration
+	// ReconnectBackoff is the wait before every reconnection, defaults to exponential from 500ms to 30 seconds
+	ReconnectBackoff BackoffStrategy
+	// MaxReconnects is the max amount of failed reconnections in a row before closing the client, zero means no limit
+	MaxReconnects int
+	// MaxMessageSize defaults to 10MB
+	MaxMessageSize int64
+	// SubscriptionBuffer is the amount of notifications kept for every subscription, defaults to 128
+	SubscriptionBuffer int
+	// Logger logs the connection changes and the calls, with the same levels as the request logging
+	Logger *logger.Logger
+	// Metrics receives the metrics of every call, with the JSON-RPC method as method and,
+	// as there is no status code, 200 once a response is received
+	Metrics MetricsRecorder
+}
+
+// withDefaults returns a copy of the options with the defaults where not set
+func (o WSRPCOpts) withDefaults() WSRPCOpts {
+	o.DialTimeout = valueOrDefault(o.DialTimeout, defaultWSDialTimeout)
+	o.WriteTimeout = valueOrDefault(o.WriteTimeout, defaultWSWriteTimeout)
+	o.PingInterval = valueOrDefault(o.PingInterval, defaultWSPingInterval)
+	o.PongTimeout = valueOrDefault(o.PongTimeout, defaultWSPongTimeout)
+	o.SubscriptionBuffer = valueOrDefault(o.SubscriptionBuffer, defaultWSSubscriptionBuffer)
+
+	if o.ReconnectBackoff == nil {
+		o.ReconnectBackoff = ExponentialBackoff(defaultWSMinReconnectDelay, defaultWSMaxReconnectDelay)
+	}
+
+	return o
+}
+
+// WSRPCClient does JSON-RPC 2.0 calls and subscriptions over a WebSocket connection
+// once the connection is lost it reconnects and subscribes again to all the active subscriptions,
+// the calls done while reconnecting wait for it
+type WSRPCClient struct {
+	url        string
+	httpClient *http.Client
+	host       string
+	logURL     string
+	opts       WSRPCOpts
+	nextID     atomic.Uint64
+	// ctx is cancelled once the client is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	ready   chan struct{}
+	err     error
+	pending map[uint64]*wsCall
+	subs    map[string]*Subscription
+	active  map[*Subscription]struct{}
+}
+
+type wsCall struct {
+	result chan wsCallResult
+	sub    *Subscription
+}
+
+type wsCallResult struct {
+	resp *rpcResponse
+	err  error
+}
+
+// wsMessage is a call response or a subscription notification
+type wsMessage struct {
+	rpcResponse
+	Params *wsNotification `json:"params"`
+}
+
+type wsNotification struct {
+	Subscription json.RawMessage `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+}
+
+// Subscription receives the notifications of a subscription done with WSRPCClient.Subscribe
+type Subscription struct {
+	client        *WSRPCClient
+	method        string
+	params        any
+	notifications chan json.RawMessage
+	err           chan error
+	// id, conn and done are guarded by the client lock
+	id   string
+	conn *websocket.Conn
+	done bool
+}
+
+// DialWSRPC connects to the ws or wss URL, the context just bounds the first connection
+func DialWSRPC(ctx context.Context, rawURL string, opts WSRPCOpts) (*WSRPCClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = opts.withDefaults()
+	clientCtx, cancel := context.WithCancel(context.Background())
+
+	c := &WSRPCClient{
+		url:        rawURL,
+		httpClient: &http.Client{Transport: clientTransport(opts.Transport, opts.TransportOpts)},
+		host:       u.Host,
+		logURL:     redactURL(u, defaultRedactQueryParams),
+		opts:       opts,
+		ctx:        clientCtx,
+		cancel:     cancel,
+		ready:      make(chan struct{}),
+		pending:    make(map[uint64]*wsCall),
+		subs:       make(map[string]*Subscription),
+		active:     make(map[*Subscription]struct{}),
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	c.setConn(conn)
+	c.log(slog.LevelDebug, "websocket connected")
+
+	return c, nil
+}
+
+// Call does a JSON-RPC call decoding its result into the result pointer
+// errors returned by the node are returned as *RPCError
+func (c *WSRPCClient) Call(ctx context.Context, method string, params any, result any) error {
+	start := time.Now()
+
+	resp, err := c.request(ctx, method, params, nil)
+	if err == nil {
+		err = resp.decode(result)
+	}
+
+	c.observe(method, time.Since(start), resp != nil, err)
+
+	return err
+}
+
+// Subscribe calls the subscribe method, like eth_subscribe, and returns the subscription receiving its notifications
+// the subscription is done again with the same params after reconnecting
+func (c *WSRPCClient) Subscribe(ctx context.Context, method string, params any) (*Subscription, error) {
+	sub := &Subscription{
+		client:        c,
+		method:        method,
+		params:        params,
+		notifications: make(chan json.RawMessage, c.opts.SubscriptionBuffer),
+		err:           make(chan error, 1),
+	}
+
+	if err := c.subscribe(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// Close closes the connection with the close handshake, ending all the subscriptions and failing the calls in flight
+func (c *WSRPCClient) Close() error {
+	conn := c.shutdown(ErrWSRPCClosed, nil)
+	if conn == nil {
+		return nil
+	}
+
+	return conn.Close(websocket.StatusNormalClosure, "")
+}
+
+func (c *WSRPCClient) subscribe(ctx context.Context, sub *Subscription) error {
+	start := time.Now()
+
+	resp, err := c.request(ctx, sub.method, sub.params, sub)
+	if err == nil {
+		err = resp.decode(nil)
+	}
+
+	c.observe(sub.method, time.Since(start), resp != nil, err)
+
+	return err
+}
+
+// request sends the call and waits for its response
+func (c *WSRPCClient) request(ctx context.Context, method string, params any, sub *Subscription) (*rpcResponse, error) {
+	id := c.nextID.Add(1)
+
+	data, err := json.Marshal(rpcRequest{JSONRPC: jsonRPCVersion, ID: id, Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+
+	call := &wsCall{result: make(chan wsCallResult, 1), sub: sub}
+
+	conn, err := c.register(ctx, id, call)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.write(conn, data); err != nil {
+		c.unregister(id)
+		return nil, err
+	}
+
+	select {
+	case result := <-call.result:
+		return result.resp, result.err
+	case <-ctx.Done():
+		c.unregister(id)
+		return nil, ctx.Err()
+	}
+}
+
+// write sends the message, a write that times out closes the connection
+func (c *WSRPCClient) write(conn *websocket.Conn, data []byte) error {
+	ctx, cancel := context.WithTimeout(c.ctx, c.opts.WriteTimeout)
+	defer cancel()
+
+	return conn.Write(ctx, websocket.MessageText, data)
+}
+
+// register adds the call to the pending ones, waiting for the client to reconnect if needed
+func (c *WSRPCClient) register(ctx context.Context, id uint64, call *wsCall) (*websocket.Conn, error) {
+	for {
+		c.mu.Lock()
+		if c.err != nil {
+			err := c.err
+			c.mu.Unlock()
+
+			return nil, err
+		}
+
+		if c.conn != nil {
+			c.pending[id] = call
+			conn := c.conn
+			c.mu.Unlock()
+
+			return conn, nil
+		}
+
+		ready := c.ready
+		c.mu.Unlock()
+
+		select {
+		case <-ready:
+		case <-c.ctx.Done():
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *WSRPCClient) unregister(id uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.pending, id)
+}
+
+func (c *WSRPCClient) dial(ctx context.Context) (*websocket.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.DialTimeout)
+	defer cancel()
+
+	conn, err := dialWebSocket(ctx, c.url, c.opts.Header, c.httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetReadLimit(valueOrDefault(c.opts.MaxMessageSize, defaultMaxResponseSize))
+
+	return conn, nil
+}
+
+// setConn starts using the connection, unless the client was closed meanwhile
+func (c *WSRPCClient) setConn(conn *websocket.Conn) bool {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		_ = conn.Close(websocket.StatusNormalClosure, "")
+
+		return false
+	}
+
+	c.conn = conn
+	close(c.ready)
+	c.mu.Unlock()
+
+	go c.readLoop(conn)
+
+	if c.opts.PingInterval > 0 {
+		go c.pingLoop(conn)
+	}
+
+	return true
+}
+
+func (c *WSRPCClient) readLoop(conn *websocket.Conn) {
+	for {
+		// The reads are not bound to the client context, so closing it doesn't cut the close handshake
+		_, message, err := conn.Read(context.Background())
+		if err != nil {
+			c.disconnected(conn, err)
+			return
+		}
+
+		c.handleMessage(conn, message)
+	}
+}
+
+func (c *WSRPCClient) pingLoop(conn *websocket.Conn) {
+	ticker := time.NewTicker(c.opts.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !c.isCurrent(conn) {
+			return
+		}
+
+		// A ping without pong drops the connection, which the read loop notices
+		if err := c.ping(conn); err != nil {
+			if c.ctx.Err() == nil {
+				_ = conn.CloseNow()
+			}
+
+			return
+		}
+	}
+}
+
+// ping sends a ping and waits for its pong, which is received by the read loop
+func (c *WSRPCClient) ping(conn *websocket.Conn) error {
+	ctx, cancel := context.WithTimeout(c.ctx, c.opts.PongTimeout)
+	defer cancel()
+
+	return conn.Ping(ctx)
+}
+
+func (c *WSRPCClient) isCurrent(conn *websocket.Conn) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.conn == conn
+}
+
+func (c *WSRPCClient) handleMessage(conn *websocket.Conn, data []byte) {
+	var msg wsMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.log(slog.LevelWarn, "invalid websocket message", "error", err.Error())
+		return
+	}
+
+	if len(msg.ID) == 0 && msg.Params != nil {
+		c.notify(msg.Params)
+		return
+	}
+
+	c.resolve(conn, &msg.rpcResponse)
+}
+
+// resolve hands the response to its call, registering the subscription ID if it was a subscription
+func (c *WSRPCClient) resolve(conn *websocket.Conn, resp *rpcResponse) {
+	id, err := strconv.ParseUint(resp.key(), 10, 64)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	call, ok := c.pending[id]
+	delete(c.pending, id)
+
+	if ok && call.sub != nil && resp.Error == nil && !call.sub.done {
+		sub := call.sub
+		sub.id = strings.Trim(string(resp.Result), `"`)
+		sub.conn = conn
+		c.subs[sub.id] = sub
+		c.active[sub] = struct{}{}
+	}
+	c.mu.Unlock()
+
+	if ok {
+		call.result <- wsCallResult{resp: resp}
+	}
+}
+
+// notify hands the notification to its subscription, ending it if its buffer is full
+func (c *WSRPCClient) notify(notification *wsNotification) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sub, ok := c.subs[strings.Trim(string(notification.Subscription), `"`)]
+	if !ok {
+		return
+	}
+
+	select {
+	case sub.notifications <- notification.Result:
+	default:
+		c.log(slog.LevelWarn, "websocket subscription overflow", "method", sub.method, "subscription", sub.id)
+		go c.unsubscribe(sub.method, sub.id)
+		c.endSubscription(sub, ErrSubscriptionOverflow)
+	}
+}
+
+// endSubscription removes the subscription closing its channels, must be called with the lock held
+func (c *WSRPCClient) endSubscription(sub *Subscription, err error) {
+	if sub.done {
+		return
+	}
+
+	sub.done = true
+	delete(c.active, sub)
+
+	if c.subs[sub.id] == sub {
+		delete(c.subs, sub.id)
+	}
+
+	if err != nil {
+		sub.err <- err
+	}
+
+	close(sub.err)
+	close(sub.notifications)
+}
+
+// unsubscribe calls the unsubscribe method of the subscribe one, like eth_unsubscribe for eth_subscribe
+func (c *WSRPCClient) unsubscribe(method, id string) error {
+	namespace, _, _ := strings.Cut(method, "_")
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.opts.WriteTimeout)
+	defer cancel()
+
+	return c.Call(ctx, namespace+"_unsubscribe", []string{id}, nil)
+}
+
+// disconnected fails the calls in flight and starts reconnecting, unless the client was closed
+func (c *WSRPCClient) disconnected(conn *websocket.Conn, err error) {
+	c.mu.Lock()
+	if c.conn != conn {
+		c.mu.Unlock()
+		return
+	}
+
+	c.conn = nil
+	c.ready = make(chan struct{})
+	pending := c.pending
+	c.pending = make(map[uint64]*wsCall)
+	// The subscription IDs are not valid on the next connection
+	c.subs = make(map[string]*Subscription)
+	c.mu.Unlock()
+
+	_ = conn.CloseNow()
+
+	failCalls(pending, fmt.Errorf("%w: %w", ErrWSConnectionLost, err))
+	c.log(slog.LevelWarn, "websocket connection lost", "error", err.Error())
+
+	go c.reconnect()
+}
+
+// reconnect dials until it connects or runs out of reconnections, closing the client in that case
+func (c *WSRPCClient) reconnect() {
+	var delay time.Duration
+
+	for attempt := 1; c.opts.MaxReconnects == 0 || attempt <= c.opts.MaxReconnects; attempt++ {
+		delay = c.opts.ReconnectBackoff(attempt, delay)
+		if sleepWithContext(c.ctx, delay) != nil {
+			return
+		}
+
+		conn, err := c.dial(c.ctx)
+		if err != nil {
+			c.log(slog.LevelWarn, "websocket reconnect failed", "attempt", attempt, "error", err.Error())
+			continue
+		}
+
+		if c.setConn(conn) {
+			c.log(slog.LevelInfo, "websocket reconnected", "attempt", attempt)
+			c.resubscribe(conn)
+		}
+
+		return
+	}
+
+	c.log(slog.LevelError, "websocket out of reconnections")
+
+	err := fmt.Errorf("%w: out of reconnections", ErrWSConnectionLost)
+	c.shutdown(err, err)
+}
+
+// resubscribe subscribes again to the active subscriptions that were done on a previous connection
+func (c *WSRPCClient) resubscribe(conn *websocket.Conn) {
+	c.mu.Lock()
+	subs := make([]*Subscription, 0, len(c.active))
+	for sub := range c.active {
+		if sub.conn != conn {
+			subs = append(subs, sub)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		ctx, cancel := context.WithTimeout(c.ctx, c.opts.WriteTimeout)
+		err := c.subscribe(ctx, sub)
+		cancel()
+
+		// Losing the connection again starts another reconnection that subscribes again
+		if errors.Is(err, ErrWSConnectionLost) || errors.Is(err, ErrWSRPCClosed) {
+			return
+		}
+
+		if err != nil {
+			c.log(slog.LevelError, "websocket resubscribe failed", "method", sub.method, "error", err.Error())
+
+			c.mu.Lock()
+			c.endSubscription(sub, err)
+			c.mu.Unlock()
+		}
+	}
+}
+
+// shutdown closes the client with the error, returning its connection if it had one
+// subscriptions are ended with subErr
+func (c *WSRPCClient) shutdown(err, subErr error) *websocket.Conn {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil
+	}
+
+	c.err = err
+	conn := c.conn
+	c.conn = nil
+	pending := c.pending
+	c.pending = make(map[uint64]*wsCall)
+
+	for sub := range c.active {
+		c.endSubscription(sub, subErr)
+	}
+	c.mu.Unlock()
+
+	c.cancel()
+	failCalls(pending, err)
+
+	return conn
+}
+
+func failCalls(calls map[uint64]*wsCall, err error) {
+	for _, call := range calls {
+		call.result <- wsCallResult{err: err}
+	}
+}
+
+// observe records the call on the metrics and logs
+func (c *WSRPCClient) observe(method string, duration time.Duration, responded bool, err error) {
+	if c.opts.Metrics != nil {
+		metrics := RequestMetrics{Host: c.host, Method: method, Attempts: 1, Duration: duration, Err: err}
+		if responded {
+			metrics.StatusCode = http.StatusOK
+		}
+
+		c.opts.Metrics.ObserveRequest(metrics)
+	}
+
+	if err != nil {
+		c.log(slog.LevelWarn, "websocket call failed", "method", method, "duration", duration, "error", err.Error())
+		return
+	}
+
+	c.log(slog.LevelDebug, "websocket call done", "method", method, "duration", duration)
+}
+
+func (c *WSRPCClient) log(level slog.Level, msg string, args ...any) {
+	if c.opts.Logger == nil {
+		return
+	}
+
+	c.opts.Logger.Log(context.Background(), level, msg, append([]any{"url", c.logURL}, args...)...)
+}
+
+// ID returns the subscription ID, it changes every time the client subscribes again after reconnecting
+func (s *Subscription) ID() string {
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	return s.id
+}
+
+// Notifications returns the channel receiving the notification results, closed once the subscription ends
+func (s *Subscription) Notifications() <-chan json.RawMessage {
+	return s.notifications
+}
+
+// Err returns the channel receiving the error the subscription failed with, like ErrSubscriptionOverflow
+// it is closed once the subscription ends
+func (s *Subscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe ends the subscription and calls the unsubscribe method, like eth_unsubscribe for eth_subscribe
+func (s *Subscription) Unsubscribe() error {
+	s.client.mu.Lock()
+	if s.done {
+		s.client.mu.Unlock()
+		return nil
+	}
+
+	id := s.id
+	s.client.endSubscription(s, nil)
+	s.client.mu.Unlock()
+
+	return s.client.unsubscribe(s.method, id)
+}
@@ -0,0 +1,320 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRPCServer is a WebSocket JSON-RPC server that sends 3 notifications after every subscription
+// and drops the connection when the drop method is called
+type testRPCServer struct {
+	*httptest.Server
+	connections  atomic.Int32
+	mu           sync.Mutex
+	unsubscribed []string
+}
+
+func newTestRPCServer(t *testing.T) *testRPCServer {
+	server := &testRPCServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		server.serve(conn, server.connections.Add(1))
+	}))
+
+	return server
+}
+
+func (s *testRPCServer) url() string {
+	return strings.Replace(s.URL, "http://", "ws://", 1)
+}
+
+func (s *testRPCServer) serve(conn *websocket.Conn, connection int32) {
+	defer conn.CloseNow()
+
+	write := func(format string, args ...any) {
+		_ = conn.Write(context.Background(), websocket.MessageText, []byte(fmt.Sprintf(format, args...)))
+	}
+
+	for {
+		_, message, err := conn.Read(context.Background())
+		if err != nil {
+			return
+		}
+
+		var req struct {
+			ID     uint64   `json:"id"`
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+		}
+		if json.Unmarshal(message, &req) != nil {
+			return
+		}
+
+		switch req.Method {
+		case "drop":
+			return
+		case "eth_blockNumber":
+			write(`{"jsonrpc":"2.0","id":%d,"result":"0x10"}`, req.ID)
+		case "eth_subscribe":
+			subID := fmt.Sprintf("0xsub%d", connection)
+			write(`{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.ID, subID)
+
+			for i := 1; i <= 3; i++ {
+				write(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"%s","result":{"number":%d}}}`, subID, i)
+			}
+		case "eth_unsubscribe":
+			s.mu.Lock()
+			s.unsubscribed = append(s.unsubscribed, req.Params...)
+			s.mu.Unlock()
+
+			write(`{"jsonrpc":"2.0","id":%d,"result":true}`, req.ID)
+		case "eth_wait":
+			// Never answered
+		default:
+			write(`{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
+		}
+	}
+}
+
+func TestWSRPCClient_Call(t *testing.T) {
+	c := require.New(t)
+
+	server := newTestRPCServer(t)
+	defer server.Close()
+
+	metrics := NewPrometheusMetrics("", nil)
+
+	client, err := DialWSRPC(context.Background(), server.url(), WSRPCOpts{Metrics: metrics})
+	c.NoError(err)
+	defer client.Close()
+
+	var blockNumber string
+	c.NoError(client.Call(context.Background(), "eth_blockNumber", nil, &blockNumber))
+	c.Equal("0x10", blockNumber)
+
+	err = client.Call(context.Background(), "eth_dummy", nil, nil)
+
+	var rpcErr *RPCError
+	c.ErrorAs(err, &rpcErr)
+	c.Equal(-32601, rpcErr.Code)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	c.ErrorIs(client.Call(ctx, "eth_wait", nil, nil), context.DeadlineExceeded)
+
+	var buf strings.Builder
+	_, err = metrics.WriteTo(&buf)
+	c.NoError(err)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	c.Contains(buf.String(), fmt.Sprintf(`http_client_requests_total{host="%s",method="eth_blockNumber",status_class="2xx"} 1`, host))
+	c.Contains(buf.String(), fmt.Sprintf(`http_client_requests_total{host="%s",method="eth_wait",status_class="error"} 1`, host))
+}
+
+func TestWSRPCClient_SubscribeAndReconnect(t *testing.T) {
+	c := require.New(t)
+
+	server := newTestRPCServer(t)
+	defer server.Close()
+
+	client, err := DialWSRPC(context.Background(), server.url(), WSRPCOpts{ReconnectBackoff: ConstantBackoff(10 * time.Millisecond)})
+	c.NoError(err)
+	defer client.Close()
+
+	sub, err := client.Subscribe(context.Background(), "eth_subscribe", []any{"newHeads"})
+	c.NoError(err)
+	c.Equal("0xsub1", sub.ID())
+
+	readNotifications := func() {
+		for i := 1; i <= 3; i++ {
+			select {
+			case notification := <-sub.Notifications():
+				c.JSONEq(fmt.Sprintf(`{"number":%d}`, i), string(notification))
+			case <-time.After(time.Second):
+				c.Fail("notification not received")
+			}
+		}
+	}
+
+	readNotifications()
+
+	// Losing the connection fails the calls in flight, then the client reconnects and subscribes again
+	err = client.Call(context.Background(), "drop", nil, nil)
+	c.ErrorIs(err, ErrWSConnectionLost)
+
+	readNotifications()
+	c.Equal("0xsub2", sub.ID())
+	c.Equal(int32(2), server.connections.Load())
+
+	var blockNumber string
+	c.NoError(client.Call(context.Background(), "eth_blockNumber", nil, &blockNumber))
+
+	c.NoError(sub.Unsubscribe())
+	c.Equal([]string{"0xsub2"}, server.unsubscribed)
+
+	_, ok := <-sub.Notifications()
+	c.False(ok)
+
+	_, ok = <-sub.Err()
+	c.False(ok)
+	c.NoError(sub.Unsubscribe())
+}
+
+func TestWSRPCClient_SubscriptionOverflow(t *testing.T) {
+	c := require.New(t)
+
+	server := newTestRPCServer(t)
+	defer server.Close()
+
+	client, err := DialWSRPC(context.Background(), server.url(), WSRPCOpts{SubscriptionBuffer: 2})
+	c.NoError(err)
+	defer client.Close()
+
+	sub, err := client.Subscribe(context.Background(), "eth_subscribe", []any{"newHeads"})
+	c.NoError(err)
+
+	c.ErrorIs(<-sub.Err(), ErrSubscriptionOverflow)
+	c.Eventually(func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		return len(server.unsubscribed) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWSRPCClient_Close(t *testing.T) {
+	c := require.New(t)
+
+	server := newTestRPCServer(t)
+	defer server.Close()
+
+	client, err := DialWSRPC(context.Background(), server.url(), WSRPCOpts{})
+	c.NoError(err)
+
+	sub, err := client.Subscribe(context.Background(), "eth_subscribe", []any{"newHeads"})
+	c.NoError(err)
+
+	callErr := make(chan error)
+
+	go func() {
+		callErr <- client.Call(context.Background(), "eth_wait", nil, nil)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.NoError(client.Close())
+	c.ErrorIs(<-callErr, ErrWSRPCClosed)
+	c.ErrorIs(client.Call(context.Background(), "eth_blockNumber", nil, nil), ErrWSRPCClosed)
+
+	_, ok := <-sub.Err()
+	c.False(ok)
+	c.NoError(client.Close())
+}
+
+func TestWSRPCClient_PingTimeout(t *testing.T) {
+	c := require.New(t)
+
+	var connections atomic.Int32
+
+	// The server never reads, so it doesn't answer the pings
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		connections.Add(1)
+
+		time.Sleep(200 * time.Millisecond)
+		_ = conn.CloseNow()
+	}))
+	defer server.Close()
+
+	client, err := DialWSRPC(context.Background(), strings.Replace(server.URL, "http://", "ws://", 1), WSRPCOpts{
+		PingInterval:     20 * time.Millisecond,
+		PongTimeout:      20 * time.Millisecond,
+		ReconnectBackoff: ConstantBackoff(time.Millisecond),
+	})
+	c.NoError(err)
+	defer client.Close()
+
+	c.Eventually(func() bool { return connections.Load() >= 2 }, time.Second, 10*time.Millisecond)
+
+	// A server answering the pings keeps the connection
+	rpcServer := newTestRPCServer(t)
+	defer rpcServer.Close()
+
+	rpcClient, err := DialWSRPC(context.Background(), rpcServer.url(), WSRPCOpts{
+		PingInterval: 20 * time.Millisecond,
+		PongTimeout:  20 * time.Millisecond,
+	})
+	c.NoError(err)
+	defer rpcClient.Close()
+
+	time.Sleep(150 * time.Millisecond)
+	c.Equal(int32(1), rpcServer.connections.Load())
+}
+
+func TestWSRPCClient_OutOfReconnects(t *testing.T) {
+	c := require.New(t)
+
+	server := newTestRPCServer(t)
+	defer server.Close()
+
+	client, err := DialWSRPC(context.Background(), server.url(), WSRPCOpts{
+		ReconnectBackoff: ConstantBackoff(time.Millisecond),
+		MaxReconnects:    2,
+	})
+	c.NoError(err)
+	defer client.Close()
+
+	sub, err := client.Subscribe(context.Background(), "eth_subscribe", []any{"newHeads"})
+	c.NoError(err)
+
+	// No more connections are accepted once the current one is dropped
+	c.NoError(server.Listener.Close())
+	c.ErrorIs(client.Call(context.Background(), "drop", nil, nil), ErrWSConnectionLost)
+
+	c.ErrorIs(<-sub.Err(), ErrWSConnectionLost)
+	c.ErrorIs(client.Call(context.Background(), "eth_blockNumber", nil, nil), ErrWSConnectionLost)
+}
+
+func TestWSRPCClient_TransportOpts(t *testing.T) {
+	c := require.New(t)
+
+	server := newTestRPCServer(t)
+	defer server.Close()
+
+	var handshakes atomic.Int32
+
+	client, err := DialWSRPC(context.Background(), server.url(), WSRPCOpts{
+		Transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			handshakes.Add(1)
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+	})
+	c.NoError(err)
+	defer client.Close()
+
+	c.NoError(client.Call(context.Background(), "eth_blockNumber", nil, nil))
+	c.Equal(int32(1), handshakes.Load())
+
+	_, err = DialWSRPC(context.Background(), server.url(), WSRPCOpts{TransportOpts: &TransportOpts{ProxyURL: "://dummy"}})
+	c.ErrorContains(err, "invalid transport options")
+}
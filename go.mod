@@ -3,8 +3,11 @@ module github.com/pokt-foundation/utils-go
 go 1.21
 
 require (
+	github.com/andybalholm/brotli v1.1.1
+	github.com/coder/websocket v1.8.13
 	github.com/jarcoal/httpmock v1.2.0
 	github.com/joho/godotenv v1.4.0
+	github.com/klauspost/compress v1.17.11
 	github.com/stretchr/testify v1.8.0
 )
 
@@ -0,0 +1,390 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// RedactedValue replaces the redacted values on the recorded interactions
+const RedactedValue = "REDACTED"
+
+var (
+	// ErrInteractionNotFound when a replayed request doesn't match any recorded interaction
+	ErrInteractionNotFound = errors.New("recorded interaction not found")
+
+	defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+)
+
+// Cassette is a list of recorded interactions, saved to disk as JSON
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request and the response it got
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request saved on a cassette
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response saved on a cassette
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// LoadCassette reads the cassette saved on the file
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, err
+	}
+
+	return &cassette, nil
+}
+
+// Save writes the cassette to the file as indented JSON, so it can be reviewed like any other fixture
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// RecorderOpts are the options of the Recorder
+type RecorderOpts struct {
+	// RedactHeaders are the request and response headers saved as RedactedValue,
+	// defaults to Authorization, Proxy-Authorization, Cookie and Set-Cookie
+	RedactHeaders []string
+	// RedactQueryParams are the query params saved as RedactedValue, they match any value on replay
+	RedactQueryParams []string
+	// RedactBody is called with the request and response bodies before saving them,
+	// give it to ReplayOpts too so the requests with a redacted body match on replay
+	RedactBody func(body string) string
+}
+
+// Recorder records the requests sent through its transport and their responses, to be saved as a cassette
+// the responses bodies are read whole before being returned, so it is not meant for streams
+type Recorder struct {
+	mu       sync.Mutex
+	opts     RecorderOpts
+	cassette Cassette
+}
+
+// NewRecorder returns a recorder with the given options
+func NewRecorder(opts RecorderOpts) *Recorder {
+	if opts.RedactHeaders == nil {
+		opts.RedactHeaders = defaultRedactedHeaders
+	}
+
+	return &Recorder{opts: opts}
+}
+
+// Transport wraps the transport recording every request sent through it, a nil transport uses
+// http.DefaultTransport. As a func of a round tripper it can be used as a client.Middleware
+func (r *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return r.roundTrip(next, req)
+	})
+}
+
+// Cassette returns a copy of the interactions recorded so far
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// Save writes the interactions recorded so far to the file
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+func (r *Recorder) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// the original request is never modified, the clone gets its own body
+	sent := req.Clone(req.Context())
+	sent.Body = io.NopCloser(bytes.NewReader(reqBody))
+
+	resp, err := next.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.record(Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    r.redactURL(req.URL),
+			Header: r.redactHeader(req.Header),
+			Body:   r.redactBody(reqBody),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.redactHeader(resp.Header),
+			Body:       r.redactBody(respBody),
+		},
+	})
+
+	return resp, nil
+}
+
+func (r *Recorder) record(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+}
+
+func (r *Recorder) redactURL(reqURL *url.URL) string {
+	redacted := *reqURL
+	query := redacted.Query()
+
+	for _, param := range r.opts.RedactQueryParams {
+		if query.Has(param) {
+			query.Set(param, RedactedValue)
+		}
+	}
+
+	redacted.RawQuery = query.Encode()
+
+	return redacted.String()
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+
+	for _, key := range r.opts.RedactHeaders {
+		if redacted.Get(key) != "" {
+			redacted.Set(key, RedactedValue)
+		}
+	}
+
+	return redacted
+}
+
+func (r *Recorder) redactBody(body []byte) string {
+	if r.opts.RedactBody == nil {
+		return string(body)
+	}
+
+	return r.opts.RedactBody(string(body))
+}
+
+// ReplayOpts are the rules to match the requests with the recorded interactions,
+// which always match on method, scheme, host and path
+type ReplayOpts struct {
+	// IgnoreQuery matches the requests regardless of their query params
+	IgnoreQuery bool
+	// IgnoreBody matches the requests regardless of their bodies, otherwise they must be equal,
+	// or hold the same value if both are JSON
+	IgnoreBody bool
+	// RedactBody is called with the request bodies before matching them,
+	// it should be the same func given to the Recorder that saved the cassette
+	RedactBody func(body string) string
+}
+
+// AddMockedResponsesFromCassette registers on httpmock the interactions recorded on the cassette file
+// each request gets the response of the first matching interaction not replayed yet,
+// once all of them were replayed the last matching one is repeated
+func AddMockedResponsesFromCassette(path string, opts ReplayOpts) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		panic(err)
+	}
+
+	AddMockedResponsesFromInteractions(cassette.Interactions, opts)
+}
+
+// AddMockedResponsesFromInteractions registers on httpmock the given interactions,
+// as AddMockedResponsesFromCassette does
+func AddMockedResponsesFromInteractions(interactions []Interaction, opts ReplayOpts) {
+	replayer := &replayer{
+		interactions: interactions,
+		replayed:     make([]bool, len(interactions)),
+		opts:         opts,
+	}
+
+	registered := make(map[string]bool)
+
+	for _, interaction := range interactions {
+		reqURL, err := url.Parse(interaction.Request.URL)
+		if err != nil {
+			panic(err)
+		}
+
+		reqURL.RawQuery = ""
+		key := interaction.Request.Method + " " + reqURL.String()
+
+		if !registered[key] {
+			registered[key] = true
+			registerResponder(interaction.Request.Method, reqURL.String(), replayer.respond)
+		}
+	}
+}
+
+type replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     []bool
+	opts         ReplayOpts
+}
+
+func (r *replayer) respond(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.opts.RedactBody != nil {
+		body = []byte(r.opts.RedactBody(string(body)))
+	}
+
+	interaction, ok := r.match(req, body)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL)
+	}
+
+	resp := httpmock.NewStringResponse(interaction.Response.StatusCode, interaction.Response.Body)
+	resp.Header = interaction.Response.Header.Clone()
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	resp.Request = req
+
+	return resp, nil
+}
+
+// match returns the first matching interaction not replayed yet, or the last matching one
+func (r *replayer) match(req *http.Request, body []byte) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := -1
+
+	for i, interaction := range r.interactions {
+		if !r.matches(interaction.Request, req, body) {
+			continue
+		}
+
+		if !r.replayed[i] {
+			r.replayed[i] = true
+			return interaction, true
+		}
+
+		last = i
+	}
+
+	if last == -1 {
+		return Interaction{}, false
+	}
+
+	return r.interactions[last], true
+}
+
+func (r *replayer) matches(recorded RecordedRequest, req *http.Request, body []byte) bool {
+	recordedURL, err := url.Parse(recorded.URL)
+	if err != nil || recorded.Method != req.Method {
+		return false
+	}
+
+	if recordedURL.Scheme != req.URL.Scheme || recordedURL.Host != req.URL.Host || recordedURL.Path != req.URL.Path {
+		return false
+	}
+
+	if !r.opts.IgnoreQuery && !queryMatches(recordedURL.Query(), req.URL.Query()) {
+		return false
+	}
+
+	return r.opts.IgnoreBody || bodyMatches([]byte(recorded.Body), body)
+}
+
+// queryMatches compares the query params, the redacted ones match any value
+func queryMatches(recorded, query url.Values) bool {
+	if len(recorded) != len(query) {
+		return false
+	}
+
+	for key, values := range recorded {
+		if len(values) == 1 && values[0] == RedactedValue && query.Has(key) {
+			continue
+		}
+
+		if !reflect.DeepEqual(values, query[key]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bodyMatches compares the bodies by their value when both are JSON, or byte by byte otherwise
+func bodyMatches(recorded, body []byte) bool {
+	var recordedValue, value any
+	if json.Unmarshal(recorded, &recordedValue) == nil && json.Unmarshal(body, &value) == nil {
+		return reflect.DeepEqual(recordedValue, value)
+	}
+
+	return bytes.Equal(recorded, body)
+}
+
+// readBody reads the whole body and closes it
+func readBody(body io.ReadCloser) ([]byte, error) {
+	if body == nil || body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(body)
+	if closeErr := body.Close(); err == nil {
+		err = closeErr
+	}
+
+	return data, err
+}
+
+// roundTripperFunc is an adapter to use a func as an http.RoundTripper
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
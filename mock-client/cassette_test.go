@@ -0,0 +1,219 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pokt-foundation/utils-go/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	c := require.New(t)
+
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		c.NoError(err)
+
+		w.Header().Set("Set-Cookie", "session=secret")
+		fmt.Fprintf(w, `{"call": %d, "method": %q, "body": %q}`, calls.Add(1), r.Method, body)
+	}))
+	defer server.Close()
+
+	recorder := NewRecorder(RecorderOpts{
+		RedactQueryParams: []string{"apiKey"},
+		RedactBody: func(body string) string {
+			return strings.ReplaceAll(body, "0xprivate", RedactedValue)
+		},
+	})
+
+	httpClient := client.NewCustomClientWithOptions(client.CustomClientOpts{
+		Timeout:     time.Second,
+		Middlewares: []client.Middleware{recorder.Transport},
+	})
+
+	response, err := httpClient.PostWithURLJSONParams(server.URL+"/relay?apiKey=secret", map[string]string{
+		"method": "eth_sendRawTransaction",
+		"params": "0xprivate",
+	}, http.Header{"Authorization": []string{"Bearer secret"}})
+	c.NoError(err)
+
+	body, err := io.ReadAll(response.Body)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+	c.Contains(string(body), "0xprivate")
+
+	response, err = httpClient.GetWithURLAndParams(server.URL+"/status", nil, nil)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c.NoError(recorder.Save(path))
+
+	cassette, err := LoadCassette(path)
+	c.NoError(err)
+	c.Len(cassette.Interactions, 2)
+
+	relay := cassette.Interactions[0]
+	c.Equal(http.MethodPost, relay.Request.Method)
+	c.Equal(server.URL+"/relay?apiKey="+RedactedValue, relay.Request.URL)
+	c.Equal(RedactedValue, relay.Request.Header.Get("Authorization"))
+	c.Equal(`{"method":"eth_sendRawTransaction","params":"REDACTED"}`, relay.Request.Body)
+	c.Equal(http.StatusOK, relay.Response.StatusCode)
+	c.Equal(RedactedValue, relay.Response.Header.Get("Set-Cookie"))
+	c.NotContains(relay.Response.Body, "0xprivate")
+
+	status := cassette.Interactions[1]
+	c.Equal(server.URL+"/status", status.Request.URL)
+	c.Empty(status.Request.Body)
+	c.Equal(`{"call": 2, "method": "GET", "body": ""}`, status.Response.Body)
+}
+
+func TestRecorder_Replay(t *testing.T) {
+	c := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result": "0xhash"}`)
+	}))
+	defer server.Close()
+
+	redactBody := func(body string) string {
+		return strings.ReplaceAll(body, "0xprivate", RedactedValue)
+	}
+
+	recorder := NewRecorder(RecorderOpts{RedactBody: redactBody})
+
+	httpClient := client.NewCustomClientWithOptions(client.CustomClientOpts{
+		Timeout:     time.Second,
+		Middlewares: []client.Middleware{recorder.Transport},
+	})
+
+	params := map[string]string{"method": "eth_sendRawTransaction", "params": "0xprivate"}
+
+	response, err := httpClient.PostWithURLJSONParams(server.URL+"/relay", params, nil)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c.NoError(recorder.Save(path))
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	send := func() (*http.Response, error) {
+		body, err := json.Marshal(params)
+		c.NoError(err)
+
+		return http.DefaultClient.Post(server.URL+"/relay", "application/json", bytes.NewReader(body))
+	}
+
+	// The recorded body is redacted, so the request only matches once redacted too
+	AddMockedResponsesFromCassette(path, ReplayOpts{})
+
+	_, err = send()
+	c.ErrorIs(err, ErrInteractionNotFound)
+
+	httpmock.Reset()
+	AddMockedResponsesFromCassette(path, ReplayOpts{RedactBody: redactBody})
+
+	response, err = send()
+	c.NoError(err)
+
+	body, err := io.ReadAll(response.Body)
+	c.NoError(err)
+	c.NoError(response.Body.Close())
+	c.Equal(`{"result": "0xhash"}`, string(body))
+}
+
+func TestAddMockedResponsesFromCassette(t *testing.T) {
+	c := require.New(t)
+
+	cassette := &Cassette{Interactions: []Interaction{
+		{
+			Request:  RecordedRequest{Method: http.MethodPost, URL: "https://dummy.com/relay?apiKey=REDACTED", Body: `{"method": "eth_blockNumber"}`},
+			Response: RecordedResponse{StatusCode: http.StatusOK, Header: http.Header{"X-Dummy": []string{"ohana"}}, Body: `{"result": "0x1"}`},
+		},
+		{
+			Request:  RecordedRequest{Method: http.MethodPost, URL: "https://dummy.com/relay?apiKey=REDACTED", Body: `{"method": "eth_chainId"}`},
+			Response: RecordedResponse{StatusCode: http.StatusOK, Body: `{"result": "0x64"}`},
+		},
+		{
+			Request:  RecordedRequest{Method: http.MethodGet, URL: "https://dummy.com/status?page=1"},
+			Response: RecordedResponse{StatusCode: http.StatusOK, Body: "first"},
+		},
+		{
+			Request:  RecordedRequest{Method: http.MethodGet, URL: "https://dummy.com/status?page=1"},
+			Response: RecordedResponse{StatusCode: http.StatusServiceUnavailable, Body: "second"},
+		},
+	}}
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c.NoError(cassette.Save(path))
+
+	tests := []struct {
+		name               string
+		opts               ReplayOpts
+		method             string
+		url                string
+		body               string
+		expectedStatusCode int
+		expectedBody       string
+		expectedErr        error
+	}{
+		{name: "redacted query and JSON body", method: http.MethodPost, url: "https://dummy.com/relay?apiKey=other", body: `{"method":"eth_chainId"}`, expectedStatusCode: http.StatusOK, expectedBody: `{"result": "0x64"}`},
+		{name: "first of repeated requests", method: http.MethodGet, url: "https://dummy.com/status?page=1", expectedStatusCode: http.StatusOK, expectedBody: "first"},
+		{name: "second of repeated requests", method: http.MethodGet, url: "https://dummy.com/status?page=1", expectedStatusCode: http.StatusServiceUnavailable, expectedBody: "second"},
+		{name: "last repeated request", method: http.MethodGet, url: "https://dummy.com/status?page=1", expectedStatusCode: http.StatusServiceUnavailable, expectedBody: "second"},
+		{name: "different query", method: http.MethodGet, url: "https://dummy.com/status?page=2", expectedErr: ErrInteractionNotFound},
+		{name: "ignored query", opts: ReplayOpts{IgnoreQuery: true}, method: http.MethodGet, url: "https://dummy.com/status?page=2", expectedStatusCode: http.StatusOK, expectedBody: "first"},
+		{name: "different body", method: http.MethodPost, url: "https://dummy.com/relay?apiKey=other", body: `{"method": "eth_getBalance"}`, expectedErr: ErrInteractionNotFound},
+		{name: "ignored body", opts: ReplayOpts{IgnoreBody: true}, method: http.MethodPost, url: "https://dummy.com/relay?apiKey=other", body: `{"method": "eth_getBalance"}`, expectedStatusCode: http.StatusOK, expectedBody: `{"result": "0x1"}`},
+	}
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	AddMockedResponsesFromCassette(path, ReplayOpts{})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.opts.IgnoreQuery || test.opts.IgnoreBody {
+				httpmock.Reset()
+				AddMockedResponsesFromCassette(path, test.opts)
+			}
+
+			req, err := http.NewRequest(test.method, test.url, strings.NewReader(test.body))
+			c.NoError(err)
+
+			response, err := http.DefaultClient.Do(req)
+			if test.expectedErr != nil {
+				c.ErrorIs(err, test.expectedErr)
+				return
+			}
+
+			c.NoError(err)
+			c.Equal(test.expectedStatusCode, response.StatusCode)
+
+			body, err := io.ReadAll(response.Body)
+			c.NoError(err)
+			c.NoError(response.Body.Close())
+			c.Equal(test.expectedBody, string(body))
+		})
+	}
+
+	c.Panics(func() {
+		AddMockedResponsesFromCassette("samples/not_found.json", ReplayOpts{})
+	})
+}
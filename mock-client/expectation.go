@@ -0,0 +1,166 @@
+package mock
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// CapturedRequest is a request answered by a mocked response, with its body already read
+type CapturedRequest struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+// MockedResponse is a mocked response registered with AddMatchedMockedResponse,
+// it captures the requests it answers and checks them against its expectations on Verify
+type MockedResponse struct {
+	method     string
+	url        string
+	statusCode int
+	content    string
+	matchers   []Matcher
+
+	mu       sync.Mutex
+	requests []CapturedRequest
+	// times is the amount of calls expected, -1 when any amount is fine
+	times    int
+	jsonBody string
+	headers  http.Header
+}
+
+// String returns the method and URL the response is registered on
+func (r *MockedResponse) String() string {
+	return r.method + " " + r.url
+}
+
+// Times expects the response to be returned exactly n times
+func (r *MockedResponse) Times(n int) *MockedResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.times = n
+
+	return r
+}
+
+// Once expects the response to be returned exactly one time
+func (r *MockedResponse) Once() *MockedResponse {
+	return r.Times(1)
+}
+
+// ExpectJSONBody expects every request answered to have a JSON body holding the same value as the given one
+// unlike MatchJSONBody, the requests get the response anyway and the differences are reported by Verify
+func (r *MockedResponse) ExpectJSONBody(body string) *MockedResponse {
+	mustUnmarshal(body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jsonBody = body
+
+	return r
+}
+
+// ExpectHeader expects every request answered to have the header set to the value
+// unlike MatchHeader, the requests get the response anyway and the differences are reported by Verify
+func (r *MockedResponse) ExpectHeader(key, value string) *MockedResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.headers == nil {
+		r.headers = http.Header{}
+	}
+	r.headers.Add(key, value)
+
+	return r
+}
+
+// Requests returns the requests answered so far, in the order they were received
+func (r *MockedResponse) Requests() []CapturedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.requests)
+}
+
+func (r *MockedResponse) capture(req *http.Request, body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests = append(r.requests, CapturedRequest{
+		Method: req.Method,
+		URL:    req.URL,
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+}
+
+// verify reports the unmet expectations on t
+func (r *MockedResponse) verify(t testing.TB) {
+	t.Helper()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.times != -1 {
+		assert.Equalf(t, r.times, len(r.requests), "%s (%d response) calls", r, r.statusCode)
+	}
+
+	for i, req := range r.requests {
+		if r.jsonBody != "" {
+			assert.JSONEqf(t, r.jsonBody, string(req.Body), "%s (%d response) request #%d body", r, r.statusCode, i+1)
+		}
+
+		for key, values := range r.headers {
+			assert.Equalf(t, values, req.Header.Values(key), "%s (%d response) request #%d header %s", r, r.statusCode, i+1, key)
+		}
+	}
+}
+
+// Verify fails the test with a diff for every unmet expectation of the mocked responses registered
+// with AddMatchedMockedResponse. It must be called before httpmock is reset, as the responses
+// registered before a reset are no longer verified
+func Verify(t testing.TB) {
+	t.Helper()
+
+	for _, response := range registeredResponses() {
+		response.verify(t)
+	}
+}
+
+// registeredResponses returns the responses of the routes httpmock still has, sorted by route
+func registeredResponses() []*MockedResponse {
+	responders := httpmock.DefaultTransport.Responders()
+
+	routes.mu.Lock()
+	defer routes.mu.Unlock()
+
+	keys := make([]string, 0, len(routes.m))
+	for key := range routes.m {
+		if slices.Contains(responders, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var responses []*MockedResponse
+
+	for _, key := range keys {
+		r := routes.m[key]
+
+		r.mu.Lock()
+		responses = append(responses, r.responses...)
+		r.mu.Unlock()
+	}
+
+	return responses
+}
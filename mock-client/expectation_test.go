@@ -0,0 +1,105 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pokt-foundation/utils-go/client"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingT records the errors reported instead of failing the test
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestVerify(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	chainID := AddMatchedMockedResponse(http.MethodPost, "https://dummy.com/relay", http.StatusOK, `{"result": "0x64"}`,
+		MatchJSONRPCMethod("eth_chainId")).
+		Once().
+		ExpectHeader("X-Family", "ohana")
+	blockNumber := AddMatchedMockedResponse(http.MethodPost, "https://dummy.com/relay", http.StatusOK, `{"result": "0x1"}`,
+		MatchJSONRPCMethod("eth_blockNumber")).
+		Times(2).
+		ExpectJSONBody(`{"jsonrpc": "2.0", "id": 1, "method": "eth_blockNumber"}`)
+	status := AddMatchedMockedResponse(http.MethodGet, "https://dummy.com/status", http.StatusOK, "ok")
+
+	httpClient := client.NewDefaultClient()
+
+	for _, method := range []string{"eth_chainId", "eth_blockNumber"} {
+		response, err := httpClient.PostWithURLJSONParams("https://dummy.com/relay?page=1", map[string]any{
+			"jsonrpc": "2.0",
+			"id":      2,
+			"method":  method,
+		}, http.Header{"X-Family": []string{"family"}})
+		c.NoError(err)
+		c.NoError(response.Body.Close())
+	}
+
+	requests := chainID.Requests()
+	c.Len(requests, 1)
+	c.Equal(http.MethodPost, requests[0].Method)
+	c.Equal("page=1", requests[0].URL.RawQuery)
+	c.Equal("family", requests[0].Header.Get("X-Family"))
+	c.JSONEq(`{"jsonrpc": "2.0", "id": 2, "method": "eth_chainId"}`, string(requests[0].Body))
+
+	c.Len(blockNumber.Requests(), 1)
+	c.Empty(status.Requests())
+
+	recorder := &recordingT{TB: t}
+	Verify(recorder)
+
+	report := strings.Join(recorder.errors, "\n")
+	c.Len(recorder.errors, 3)
+	c.Contains(report, `POST https://dummy.com/relay (200 response) request #1 header X-Family`)
+	c.Contains(report, `POST https://dummy.com/relay (200 response) calls`)
+	c.Contains(report, `POST https://dummy.com/relay (200 response) request #1 body`)
+	c.Contains(report, `- (string) (len=2) "id": (float64) 1,`)
+	c.Contains(report, `+ (string) (len=2) "id": (float64) 2,`)
+
+	// Responses registered before a reset are not verified
+	httpmock.Reset()
+
+	recorder = &recordingT{TB: t}
+	Verify(recorder)
+	c.Empty(recorder.errors)
+}
+
+func TestVerify_ExpectationsMet(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	AddMatchedMockedResponse(http.MethodPost, "https://dummy.com/relay", http.StatusOK, `{"result": "0x1"}`).
+		Times(2).
+		ExpectJSONBody(`{"method": "eth_blockNumber"}`).
+		ExpectHeader("X-Family", "ohana")
+
+	httpClient := client.NewDefaultClient()
+
+	for i := 0; i < 2; i++ {
+		response, err := httpClient.PostWithURLJSONParams("https://dummy.com/relay", map[string]any{
+			"method": "eth_blockNumber",
+		}, http.Header{"X-Family": []string{"ohana"}})
+		c.NoError(err)
+		c.NoError(response.Body.Close())
+	}
+
+	Verify(t)
+}
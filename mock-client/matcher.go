@@ -0,0 +1,299 @@
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jarcoal/httpmock"
+)
+
+var (
+	// ErrNoMatch when a request doesn't match any of the mocked responses registered on its method and URL
+	ErrNoMatch = errors.New("no mocked response matches the request")
+)
+
+// Matcher is a condition a request must meet to get a mocked response
+type Matcher struct {
+	description string
+	check       func(req *http.Request, body []byte) error
+}
+
+// String returns the description of the condition
+func (m Matcher) String() string {
+	return m.description
+}
+
+// NewMatcher returns a matcher with the given description that checks the requests with the func
+func NewMatcher(description string, match func(req *http.Request, body []byte) bool) Matcher {
+	return Matcher{
+		description: description,
+		check: func(req *http.Request, body []byte) error {
+			if !match(req, body) {
+				return errors.New("not matched")
+			}
+
+			return nil
+		},
+	}
+}
+
+// MatchQuery matches the requests with the query param set to the value
+func MatchQuery(key, value string) Matcher {
+	return Matcher{
+		description: fmt.Sprintf("query param %s is %q", key, value),
+		check: func(req *http.Request, _ []byte) error {
+			return checkValues(req.URL.Query()[key], value)
+		},
+	}
+}
+
+// MatchHeader matches the requests with the header set to the value
+func MatchHeader(key, value string) Matcher {
+	return Matcher{
+		description: fmt.Sprintf("header %s is %q", key, value),
+		check: func(req *http.Request, _ []byte) error {
+			return checkValues(req.Header.Values(key), value)
+		},
+	}
+}
+
+// MatchJSONBody matches the requests with a JSON body holding the same value as the given one,
+// regardless of its formatting and keys order
+func MatchJSONBody(body string) Matcher {
+	expected := mustUnmarshal(body)
+
+	return Matcher{
+		description: "JSON body is " + body,
+		check: func(_ *http.Request, body []byte) error {
+			return checkJSONBody(body, func(value any) bool {
+				return reflect.DeepEqual(expected, value)
+			})
+		},
+	}
+}
+
+// MatchPartialJSONBody matches the requests with a JSON body that has all the fields of the given one,
+// objects can have more fields than the given ones, but arrays must have the same length
+func MatchPartialJSONBody(body string) Matcher {
+	expected := mustUnmarshal(body)
+
+	return Matcher{
+		description: "JSON body contains " + body,
+		check: func(_ *http.Request, body []byte) error {
+			return checkJSONBody(body, func(value any) bool {
+				return partialMatch(expected, value)
+			})
+		},
+	}
+}
+
+// MatchJSONRPCMethod matches the JSON-RPC requests calling the method, batches are not matched
+func MatchJSONRPCMethod(method string) Matcher {
+	return Matcher{
+		description: fmt.Sprintf("JSON-RPC method is %q", method),
+		check: func(_ *http.Request, body []byte) error {
+			var call struct {
+				Method string `json:"method"`
+			}
+			if err := json.Unmarshal(body, &call); err != nil {
+				return fmt.Errorf("got a body that is not a JSON-RPC request: %w", err)
+			}
+
+			if call.Method != method {
+				return fmt.Errorf("got %q", call.Method)
+			}
+
+			return nil
+		},
+	}
+}
+
+// AddMatchedMockedResponse adds a mocked response returned just to the requests that match all the matchers
+// many of them can be registered on the same method and URL, the first one registered that matches is used
+// requests not matching any of them fail with ErrNoMatch, listing why each of them didn't match
+// the returned MockedResponse captures the requests it answers and can have expectations, see Verify
+func AddMatchedMockedResponse(method string, url string, statusCode int, content string, matchers ...Matcher) *MockedResponse {
+	response := &MockedResponse{
+		method:     method,
+		url:        url,
+		statusCode: statusCode,
+		content:    content,
+		matchers:   matchers,
+		times:      -1,
+	}
+
+	registerMatchedResponse(response)
+
+	return response
+}
+
+// mismatches returns why the request doesn't match the response, empty if it does
+func (r *MockedResponse) mismatches(req *http.Request, body []byte) []string {
+	var mismatches []string
+
+	for _, matcher := range r.matchers {
+		if err := matcher.check(req, body); err != nil {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s", matcher, err))
+		}
+	}
+
+	return mismatches
+}
+
+// route holds the responses registered on a method and URL, the routes registry is global like httpmock
+// and the routes httpmock no longer has, after a reset or once replaced by another responder, are dropped
+type route struct {
+	mu        sync.Mutex
+	responses []*MockedResponse
+}
+
+var routes = struct {
+	mu sync.Mutex
+	m  map[string]*route
+}{m: make(map[string]*route)}
+
+// registerMatchedResponse adds the response to its route, registering the route responder again
+// in case httpmock was reset or another responder was registered on the same method and URL
+func registerMatchedResponse(response *MockedResponse) {
+	key := response.String()
+
+	routes.mu.Lock()
+	defer routes.mu.Unlock()
+
+	dropStaleRoutes()
+
+	r, ok := routes.m[key]
+	if !ok {
+		r = &route{}
+		routes.m[key] = r
+	}
+
+	r.mu.Lock()
+	r.responses = append(r.responses, response)
+	r.mu.Unlock()
+
+	httpmock.RegisterResponder(response.method, response.url, r.respond)
+}
+
+// registerResponder registers a responder that is not a route, dropping the route it replaces
+func registerResponder(method, url string, responder httpmock.Responder) {
+	routes.mu.Lock()
+	delete(routes.m, method+" "+url)
+	routes.mu.Unlock()
+
+	httpmock.RegisterResponder(method, url, responder)
+}
+
+// dropStaleRoutes drops the routes whose responder httpmock no longer has, must be called with the lock held
+func dropStaleRoutes() {
+	responders := httpmock.DefaultTransport.Responders()
+
+	for key := range routes.m {
+		if !slices.Contains(responders, key) {
+			delete(routes.m, key)
+		}
+	}
+}
+
+func (r *route) respond(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var nearMisses []string
+
+	for i, response := range r.responses {
+		mismatches := response.mismatches(req, body)
+		if len(mismatches) == 0 {
+			response.capture(req, body)
+			return httpmock.NewStringResponse(response.statusCode, response.content), nil
+		}
+
+		nearMisses = append(nearMisses, fmt.Sprintf("  #%d (%d response): %s", i+1, response.statusCode, strings.Join(mismatches, "; ")))
+	}
+
+	return nil, fmt.Errorf("%w: %s %s, near misses:\n%s", ErrNoMatch, req.Method, req.URL, strings.Join(nearMisses, "\n"))
+}
+
+func checkValues(values []string, expected string) error {
+	if slices.Contains(values, expected) {
+		return nil
+	}
+
+	if len(values) == 0 {
+		return errors.New("missing")
+	}
+
+	return fmt.Errorf("got %q", strings.Join(values, ", "))
+}
+
+func checkJSONBody(body []byte, match func(value any) bool) error {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Errorf("got a body that is not JSON: %w", err)
+	}
+
+	if !match(value) {
+		return fmt.Errorf("got %s", body)
+	}
+
+	return nil
+}
+
+// partialMatch checks that the value has all the fields of the expected one
+func partialMatch(expected, value any) bool {
+	switch expected := expected.(type) {
+	case map[string]any:
+		object, ok := value.(map[string]any)
+		return ok && partialMatchObject(expected, object)
+	case []any:
+		array, ok := value.([]any)
+		return ok && partialMatchArray(expected, array)
+	default:
+		return reflect.DeepEqual(expected, value)
+	}
+}
+
+func partialMatchObject(expected, object map[string]any) bool {
+	for key, field := range expected {
+		value, ok := object[key]
+		if !ok || !partialMatch(field, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func partialMatchArray(expected, array []any) bool {
+	if len(array) != len(expected) {
+		return false
+	}
+
+	for i := range expected {
+		if !partialMatch(expected[i], array[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func mustUnmarshal(data string) any {
+	var value any
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		panic(err)
+	}
+
+	return value
+}
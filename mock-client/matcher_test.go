@@ -0,0 +1,193 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/pokt-foundation/utils-go/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchers(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name          string
+		matcher       Matcher
+		url           string
+		header        http.Header
+		body          string
+		expectedError string
+	}{
+		{name: "query", matcher: MatchQuery("page", "2"), url: "https://dummy.com?page=1&page=2"},
+		{name: "query mismatch", matcher: MatchQuery("page", "2"), url: "https://dummy.com?page=1", expectedError: `got "1"`},
+		{name: "query missing", matcher: MatchQuery("page", "2"), url: "https://dummy.com", expectedError: "missing"},
+		{name: "header", matcher: MatchHeader("x-api-key", "ohana"), header: http.Header{"X-Api-Key": []string{"ohana"}}},
+		{name: "header mismatch", matcher: MatchHeader("X-Api-Key", "ohana"), header: http.Header{"X-Api-Key": []string{"family"}}, expectedError: `got "family"`},
+		{name: "JSON body", matcher: MatchJSONBody(`{"a": 1, "b": [1, 2]}`), body: `{"b":[1,2],"a":1}`},
+		{name: "JSON body mismatch", matcher: MatchJSONBody(`{"a": 1}`), body: `{"a":1,"b":2}`, expectedError: `got {"a":1,"b":2}`},
+		{name: "JSON body not JSON", matcher: MatchJSONBody(`{"a": 1}`), body: "ohana", expectedError: "got a body that is not JSON"},
+		{name: "partial JSON body", matcher: MatchPartialJSONBody(`{"params": [{"to": "0x1"}]}`), body: `{"method":"eth_call","params":[{"to":"0x1","data":"0x"}]}`},
+		{name: "partial JSON body missing field", matcher: MatchPartialJSONBody(`{"id": null}`), body: `{"method":"eth_call"}`, expectedError: `got {"method":"eth_call"}`},
+		{name: "partial JSON body array length", matcher: MatchPartialJSONBody(`{"params": [1]}`), body: `{"params":[1,2]}`, expectedError: "got"},
+		{name: "JSON-RPC method", matcher: MatchJSONRPCMethod("eth_chainId"), body: `{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`},
+		{name: "JSON-RPC method mismatch", matcher: MatchJSONRPCMethod("eth_chainId"), body: `{"method":"eth_blockNumber"}`, expectedError: `got "eth_blockNumber"`},
+		{name: "JSON-RPC batch", matcher: MatchJSONRPCMethod("eth_chainId"), body: `[{"method":"eth_chainId"}]`, expectedError: "not a JSON-RPC request"},
+		{name: "custom", matcher: NewMatcher("has body", func(_ *http.Request, body []byte) bool { return len(body) != 0 }), expectedError: "not matched"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			url := test.url
+			if url == "" {
+				url = "https://dummy.com"
+			}
+
+			req, err := http.NewRequest(http.MethodPost, url, nil)
+			c.NoError(err)
+
+			if test.header != nil {
+				req.Header = test.header
+			}
+
+			err = test.matcher.check(req, []byte(test.body))
+			if test.expectedError == "" {
+				c.NoError(err)
+				return
+			}
+
+			c.ErrorContains(err, test.expectedError)
+		})
+	}
+
+	c.Panics(func() {
+		MatchJSONBody("ohana")
+	})
+}
+
+func TestAddMatchedMockedResponse(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	AddMatchedMockedResponse(http.MethodPost, "https://dummy.com/relay", http.StatusOK, `{"result": "0x64"}`,
+		MatchJSONRPCMethod("eth_chainId"))
+	AddMatchedMockedResponse(http.MethodPost, "https://dummy.com/relay", http.StatusOK, `{"result": "0x1"}`,
+		MatchJSONRPCMethod("eth_blockNumber"), MatchHeader("X-Family", "ohana"))
+
+	httpClient := client.NewDefaultClient()
+
+	tests := []struct {
+		name          string
+		method        string
+		header        http.Header
+		expectedBody  string
+		expectedError []string
+	}{
+		{name: "first registration", method: "eth_chainId", expectedBody: `{"result": "0x64"}`},
+		{name: "second registration", method: "eth_blockNumber", header: http.Header{"X-Family": []string{"ohana"}}, expectedBody: `{"result": "0x1"}`},
+		{
+			name:   "near misses",
+			method: "eth_blockNumber",
+			expectedError: []string{
+				ErrNoMatch.Error(),
+				`#1 (200 response): JSON-RPC method is "eth_chainId": got "eth_blockNumber"`,
+				`#2 (200 response): header X-Family is "ohana": missing`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := httpClient.PostWithURLJSONParams("https://dummy.com/relay", map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"method":  test.method,
+			}, test.header)
+			if test.expectedError != nil {
+				c.ErrorIs(err, ErrNoMatch)
+				for _, expected := range test.expectedError {
+					c.ErrorContains(err, expected)
+				}
+				return
+			}
+
+			c.NoError(err)
+
+			body, err := io.ReadAll(response.Body)
+			c.NoError(err)
+			c.NoError(response.Body.Close())
+			c.Equal(test.expectedBody, string(body))
+		})
+	}
+
+	// After a reset the old registrations are gone
+	httpmock.Reset()
+
+	AddMatchedMockedResponse(http.MethodPost, "https://dummy.com/relay", http.StatusOK, `{"result": "0x2"}`,
+		MatchPartialJSONBody(`{"method": "eth_blockNumber"}`))
+
+	_, err := httpClient.PostWithURLJSONParams("https://dummy.com/relay", map[string]any{"method": "eth_chainId"}, nil)
+	c.ErrorIs(err, ErrNoMatch)
+	c.NotContains(err.Error(), "#2")
+	c.ErrorContains(err, `JSON body contains {"method": "eth_blockNumber"}`)
+}
+
+func TestAddMatchedMockedResponse_ReplacedRoute(t *testing.T) {
+	c := require.New(t)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpClient := client.NewDefaultClient()
+
+	get := func() (string, error) {
+		response, err := httpClient.GetWithURLAndParams("https://dummy.com/status", nil, nil)
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+
+		body, err := io.ReadAll(response.Body)
+
+		return string(body), err
+	}
+
+	AddMatchedMockedResponse(http.MethodGet, "https://dummy.com/status", http.StatusOK, "first", MatchHeader("X-Family", "ohana")).Once()
+
+	// A plain responder replaces the route and a matched one replaces the plain responder
+	AddMockedResponse(http.MethodGet, "https://dummy.com/status", http.StatusOK, "plain")
+
+	body, err := get()
+	c.NoError(err)
+	c.Equal("plain", body)
+
+	second := AddMatchedMockedResponse(http.MethodGet, "https://dummy.com/status", http.StatusOK, "second")
+
+	body, err = get()
+	c.NoError(err)
+	c.Equal("second", body)
+	c.Len(second.Requests(), 1)
+
+	// The responses registered before a reset are neither reused nor verified
+	httpmock.Reset()
+
+	stale := AddMatchedMockedResponse(http.MethodGet, "https://dummy.com/stale", http.StatusOK, "stale").Once()
+	httpmock.Reset()
+
+	AddMockedResponse(http.MethodGet, "https://dummy.com/status", http.StatusOK, "plain")
+	AddMatchedMockedResponse(http.MethodGet, "https://dummy.com/status", http.StatusOK, "third", MatchHeader("X-Family", "ohana"))
+
+	_, err = get()
+	c.ErrorIs(err, ErrNoMatch)
+	c.NotContains(err.Error(), "#2")
+
+	c.Empty(stale.Requests())
+
+	recorder := &recordingT{TB: t}
+	Verify(recorder)
+	c.Empty(recorder.errors)
+}
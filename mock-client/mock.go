@@ -29,7 +29,7 @@ func AddMockedResponseFromFile(method string, url string, statusCode int, filePa
 // AddMockedResponse adds a mocked response given its content
 func AddMockedResponse(method string, url string, statusCode int, content string) {
 	responder := httpmock.NewStringResponder(statusCode, content)
-	httpmock.RegisterResponder(method, url, responder)
+	registerResponder(method, url, responder)
 }
 
 // AddMultipleMockedResponses add a mocked response given one to one from each file
@@ -57,7 +57,7 @@ func AddMultipleMockedResponses(method string, url string, statusCode int, respo
 		return req.Response, nil
 	}
 
-	httpmock.RegisterResponder(method, url, responseFunction)
+	registerResponder(method, url, responseFunction)
 }
 
 // AddMultipleMockedPlainResponses add a mocked response given one to one from each plain response
@@ -80,5 +80,5 @@ func AddMultipleMockedPlainResponses(method string, url string, statusCodes []in
 		return req.Response, nil
 	}
 
-	httpmock.RegisterResponder(method, url, responseFunction)
+	registerResponder(method, url, responseFunction)
 }